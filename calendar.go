@@ -0,0 +1,158 @@
+package templateManager
+
+/*
+Functions dedicated to building month calendar grids
+*/
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// A month laid out in whole weeks, as returned by the `calendar` template function
+type Calendar struct {
+	Year		int
+	Month		time.Month
+	Start		time.Time
+	End			time.Time
+	Previous	time.Time
+	Next		time.Time
+	Weekdays	[]time.Time
+	Weeks		[]CalendarWeek
+}
+
+// A single row of a `Calendar`
+type CalendarWeek struct {
+	Number	int
+	Days	[]CalendarDay
+}
+
+// A single day of a `Calendar`, with the items that fall on it
+type CalendarDay struct {
+	Date		time.Time
+	Day			int
+	Today		bool
+	OutOfMonth	bool
+	Weekend		bool
+	Items		[]any
+}
+
+// Reads a weekday given as a `time.Weekday`, a number (0 = Sunday) or a name
+func calendarWeekday(value any) (time.Weekday, error) {
+	if name, ok := value.(string); ok {
+		if day, ok := dateWeekdays[strings.ToLower(strings.TrimSpace(name))]; ok {
+			return day, nil
+		}
+		return time.Sunday, fmt.Errorf("unknown weekday %q", name)
+	}
+
+	day, err := interfaceHelperConvertToInt(value)
+	if err != nil || day < 0 || day > 6 {
+		return time.Sunday, fmt.Errorf("weekday must be a name or a number from 0 (Sunday) to 6")
+	}
+
+	return time.Weekday(day), nil
+}
+
+// Finds the date of a calendar item: the item itself or its `field` (a struct field or map key)
+func calendarItemDate(item reflect.Value, field string) (time.Time, error) {
+	item, nilPointer := reflectHelperCheckNilPointers(reflectHelperUnpackInterface(item))
+	if nilPointer || !item.IsValid() {
+		return time.Time{}, fmt.Errorf("items can't be nil")
+	}
+
+	if t, ok := item.Interface().(time.Time); ok {
+		return t, nil
+	}
+
+	var value reflect.Value
+	var err error
+	switch item.Kind() {
+		case reflect.Map:
+			value, err = reflectHelperGetMapValue(item, reflect.ValueOf(field))
+		case reflect.Struct:
+			value, err = reflectHelperGetStructValue(item, reflect.ValueOf(field))
+		default:
+			return time.Time{}, fmt.Errorf("can't find the date of an item of type %s", item.Type())
+	}
+
+	value = reflectHelperUnpackInterface(value)
+	if err != nil || !value.IsValid() {
+		return time.Time{}, fmt.Errorf("item has no %q date", field)
+	}
+
+	return dateValue(value.Interface())
+}
+
+/*
+Lays out a month in whole weeks starting on `firstWeekday`, flagging today, weekends and the days of neighbouring
+months. Each item (a `time.Time`, or a map / struct whose `field` holds the date) is placed on the day it falls on.
+`now` decides which day is today
+*/
+func buildCalendar(year int, month time.Month, firstWeekday time.Weekday, items reflect.Value, field string, now time.Time) (Calendar, error) {
+	if month < time.January || month > time.December {
+		return Calendar{}, fmt.Errorf("month must be from 1 to 12")
+	}
+
+	start	:= time.Date(year, month, 1, 0, 0, 0, 0, dateLocalTimezone)
+	end		:= start.AddDate(0, 1, -1)
+	first	:= start.AddDate(0, 0, -((int(start.Weekday()) - int(firstWeekday) + 7) % 7))
+	last	:= end.AddDate(0, 0, (int(firstWeekday) - int(end.Weekday()) + 6) % 7)
+
+	buckets := map[string][]any{}
+	if items, nilPointer := reflectHelperCheckNilPointers(reflectHelperUnpackInterface(items)); !nilPointer && items.IsValid() {
+		switch items.Kind() {
+			case reflect.Slice, reflect.Array:
+				for i := 0; i < items.Len(); i++ {
+					date, err := calendarItemDate(items.Index(i), field)
+					if err != nil {
+						return Calendar{}, err
+					}
+					key := date.In(dateLocalTimezone).Format("2006-01-02")
+					buckets[key] = append(buckets[key], items.Index(i).Interface())
+				}
+			default:
+				return Calendar{}, fmt.Errorf("items must be a slice or array")
+		}
+	}
+
+	calendar := Calendar{
+		Year:		year,
+		Month:		month,
+		Start:		start,
+		End:		end,
+		Previous:	start.AddDate(0, -1, 0),
+		Next:		start.AddDate(0, 1, 0),
+		Weekdays:	[]time.Time{},
+		Weeks:		[]CalendarWeek{},
+	}
+
+	today := now.In(dateLocalTimezone).Format("2006-01-02")
+	for day := first; !day.After(last); day = day.AddDate(0, 0, 7) {
+		_, number := day.AddDate(0, 0, 3).ISOWeek()
+		week := CalendarWeek{Number: number, Days: []CalendarDay{}}
+
+		for i := 0; i < 7; i++ {
+			date := day.AddDate(0, 0, i)
+			key := date.Format("2006-01-02")
+			if len(calendar.Weekdays) < 7 {
+				calendar.Weekdays = append(calendar.Weekdays, date)
+			}
+
+			week.Days = append(week.Days, CalendarDay{
+				Date:		date,
+				Day:		date.Day(),
+				Today:		key == today,
+				OutOfMonth:	date.Month() != month,
+				Weekend:	date.Weekday() == time.Saturday || date.Weekday() == time.Sunday,
+				Items:		append([]any{}, buckets[key]...),
+			})
+		}
+
+		calendar.Weeks = append(calendar.Weeks, week)
+	}
+
+	return calendar, nil
+}
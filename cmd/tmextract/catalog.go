@@ -0,0 +1,527 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/exp/slices"
+	"gopkg.in/yaml.v3"
+
+	templateManager "github.com/paul-norman/go-template-manager"
+)
+
+// The CLDR plural categories, in their standard order
+var pluralCategories = []string{"zero", "one", "two", "few", "many", "other"}
+
+// Finds the number of plural forms declared by a .po header
+var findPoPlurals = regexp.MustCompile(`nplurals\s*=\s*(\d+)`)
+
+// A single message within a catalog. JSON and YAML plural messages hold their text for each CLDR category, while .po
+// plural messages hold their numbered forms
+type catalogEntry struct {
+	key			string
+	text		string
+	plural		bool
+	plurals		map[string]string
+	forms		[]string
+	comments	[]string
+	flags		string
+	references	[]string
+	obsolete	bool
+}
+
+// A catalog file being updated. JSON catalogs whose messages were nested are written nested
+type catalog struct {
+	format	string
+	header	string
+	nested	bool
+	entries	map[string]*catalogEntry
+}
+
+// The changes made to a catalog
+type updateResult struct {
+	added		[]string
+	kept		int
+	obsolete	[]string
+}
+
+// Determines the format of a catalog from its extension (empty if it is not a catalog)
+func catalogFormat(extension string) string {
+	switch strings.ToLower(extension) {
+		case ".json":
+			return "json"
+		case ".yaml", ".yml":
+			return "yaml"
+		case ".po":
+			return "po"
+	}
+
+	return ""
+}
+
+// Merges the extracted messages into the catalog at `path` (creating it if needed). If `fill` is set, new messages
+// are filled in with their keys (for the locale that the keys are written in)
+func updateCatalog(path string, locale string, messages []templateManager.ExtractedMessage, fill bool, prune bool) (updateResult, error) {
+	result := updateResult{added: []string{}, obsolete: []string{}}
+
+	c, err := readCatalog(path)
+	if err != nil {
+		return result, err
+	}
+
+	used := map[string]bool{}
+	for _, message := range messages {
+		used[message.Key] = true
+
+		entry, ok := c.entries[message.Key]
+		if !ok {
+			entry = &catalogEntry{key: message.Key}
+			c.entries[message.Key] = entry
+			result.added = append(result.added, message.Key)
+			if fill {
+				entry.text = message.Key
+			}
+		} else {
+			result.kept++
+		}
+
+		c.setPlural(entry, message.Plural, locale, fill && !ok)
+		entry.references	= message.References
+		entry.obsolete		= false
+	}
+
+	for key, entry := range c.entries {
+		if used[key] {
+			continue
+		}
+
+		result.obsolete = append(result.obsolete, key)
+		if prune {
+			delete(c.entries, key)
+		} else {
+			entry.obsolete = true
+		}
+	}
+	sort.Strings(result.obsolete)
+
+	return result, c.write(path, locale)
+}
+
+// Converts an entry to (or from) a plural message, keeping its existing text where possible
+func (c *catalog) setPlural(entry *catalogEntry, plural bool, locale string, fill bool) {
+	if !plural {
+		if entry.plural {
+			entry.text = entry.plurals["other"]
+			if len(entry.forms) > 0 {
+				entry.text = entry.forms[0]
+			}
+		}
+		entry.plural, entry.plurals, entry.forms = false, nil, nil
+		return
+	}
+
+	if c.format == "po" {
+		count := 2
+		if match := findPoPlurals.FindStringSubmatch(c.header); match != nil {
+			count, _ = strconv.Atoi(match[1])
+		}
+
+		if !entry.plural {
+			entry.forms = []string{entry.text}
+		}
+		for len(entry.forms) < count {
+			text := ""
+			if fill {
+				text = entry.key
+			}
+			entry.forms = append(entry.forms, text)
+		}
+	} else {
+		if !entry.plural {
+			entry.plurals = map[string]string{}
+			if len(entry.text) > 0 {
+				entry.plurals["other"] = entry.text
+			}
+		}
+		for _, category := range templateManager.PluralCategories(locale) {
+			if _, ok := entry.plurals[category]; !ok {
+				entry.plurals[category] = ""
+				if fill {
+					entry.plurals[category] = entry.key
+				}
+			}
+		}
+	}
+
+	entry.plural = true
+}
+
+// Reads a catalog (an empty catalog if the file does not exist yet)
+func readCatalog(path string) (*catalog, error) {
+	c := &catalog{format: catalogFormat(filepath.Ext(path)), entries: map[string]*catalogEntry{}}
+
+	buffer, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return c, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	if c.format == "po" {
+		return c, c.readPo(buffer)
+	}
+
+	var values map[string]any
+	if c.format == "yaml" {
+		err = yaml.Unmarshal(buffer, &values)
+	} else {
+		decoder := json.NewDecoder(bytes.NewReader(buffer))
+		decoder.UseNumber()
+		err = decoder.Decode(&values)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if obsolete, ok := values[templateManager.ObsoleteMessagesKey].(map[string]any); ok {
+		delete(values, templateManager.ObsoleteMessagesKey)
+
+		err = c.addValues("", obsolete, true)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return c, c.addValues("", values, false)
+}
+
+// Adds decoded JSON / YAML values to the catalog, flattening nested keys
+func (c *catalog) addValues(prefix string, values map[string]any, obsolete bool) error {
+	for key, value := range values {
+		if len(prefix) > 0 {
+			key = prefix + "." + key
+		}
+
+		switch typed := value.(type) {
+			case map[string]any:
+				if plurals, ok := catalogPlurals(typed); ok {
+					c.entries[key] = &catalogEntry{key: key, plural: true, plurals: plurals, obsolete: obsolete}
+					continue
+				}
+
+				c.nested = true
+				err := c.addValues(key, typed, obsolete)
+				if err != nil {
+					return err
+				}
+			case []any:
+				return fmt.Errorf("message %q must be a string or an object, not a list", key)
+			case nil:
+				c.entries[key] = &catalogEntry{key: key, obsolete: obsolete}
+			default:
+				c.entries[key] = &catalogEntry{key: key, text: fmt.Sprint(typed), obsolete: obsolete}
+		}
+	}
+
+	return nil
+}
+
+// Checks whether a map is a plural message (only CLDR categories as keys, including "other")
+func catalogPlurals(values map[string]any) (map[string]string, bool) {
+	if _, ok := values["other"]; !ok {
+		return nil, false
+	}
+
+	plurals := map[string]string{}
+	for category, value := range values {
+		text, ok := value.(string)
+		if !ok || !slices.Contains(pluralCategories, category) {
+			return nil, false
+		}
+		plurals[category] = text
+	}
+
+	return plurals, true
+}
+
+// Reads a gettext .po file, keeping translator comments and flags (references are recreated)
+func (c *catalog) readPo(buffer []byte) error {
+	entry		:= &catalogEntry{}
+	context		:= ""
+	field		:= ""
+	index		:= 0
+	started		:= false
+
+	flush := func() {
+		if started {
+			if len(entry.key) == 0 && len(context) == 0 {
+				c.header = entry.text
+			} else {
+				if len(context) > 0 {
+					entry.key = context + "|" + entry.key
+				}
+				if entry.plural && len(entry.forms) == 0 {
+					entry.forms = []string{entry.text}
+				}
+				c.entries[entry.key] = entry
+			}
+		}
+		entry, context, field, started = &catalogEntry{}, "", "", false
+	}
+
+	for number, line := range strings.Split(string(buffer), "\n") {
+		line = strings.TrimSpace(line)
+
+		obsolete := strings.HasPrefix(line, "#~")
+		if obsolete {
+			line = strings.TrimSpace(line[2:])
+		}
+
+		switch {
+			case len(line) == 0:
+				flush()
+				continue
+			case strings.HasPrefix(line, "#"):
+				if strings.HasPrefix(field, "msgstr") {
+					flush()
+				}
+				if strings.HasPrefix(line, "#,") {
+					entry.flags = strings.TrimSpace(line[2:])
+				} else if line == "#" || strings.HasPrefix(line, "# ") {
+					entry.comments = append(entry.comments, line)
+				}
+				continue
+		}
+
+		keyword, quoted := "", line
+		if !strings.HasPrefix(line, `"`) {
+			keyword, quoted, _ = strings.Cut(line, " ")
+		}
+
+		value, err := strconv.Unquote(strings.TrimSpace(quoted))
+		if err != nil {
+			return fmt.Errorf("line %d: invalid string %s", number + 1, quoted)
+		}
+
+		if len(keyword) > 0 {
+			if (keyword == "msgctxt" || keyword == "msgid") && strings.HasPrefix(field, "msgstr") {
+				flush()
+			}
+
+			field, index = keyword, 0
+			if strings.HasPrefix(keyword, "msgstr[") && strings.HasSuffix(keyword, "]") {
+				field = "msgstr[]"
+				index, err = strconv.Atoi(keyword[7:len(keyword) - 1])
+				if err != nil {
+					return fmt.Errorf("line %d: invalid keyword %s", number + 1, keyword)
+				}
+			}
+		}
+
+		started			= true
+		entry.obsolete	= obsolete
+
+		switch field {
+			case "msgctxt":
+				context += value
+			case "msgid":
+				entry.key += value
+			case "msgid_plural":
+				entry.plural = true
+			case "msgstr":
+				entry.text += value
+			case "msgstr[]":
+				for len(entry.forms) <= index {
+					entry.forms = append(entry.forms, "")
+				}
+				entry.forms[index] += value
+			default:
+				return fmt.Errorf("line %d: unknown keyword %s", number + 1, keyword)
+		}
+	}
+	flush()
+
+	return nil
+}
+
+// Writes the catalog in its format
+func (c *catalog) write(path string, locale string) error {
+	keys := []string{}
+	for key := range c.entries {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if c.entries[keys[i]].obsolete != c.entries[keys[j]].obsolete {
+			return !c.entries[keys[i]].obsolete
+		}
+		return keys[i] < keys[j]
+	})
+
+	var buffer []byte
+	var err error
+	switch c.format {
+		case "po":
+			buffer = c.po(keys, locale)
+		case "yaml":
+			buffer = c.yaml(keys)
+		default:
+			buffer, err = c.json(keys)
+	}
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, buffer, 0644)
+}
+
+// Formats the catalog as JSON (with nested keys if it was read with them), keeping obsolete messages under the
+// `templateManager.ObsoleteMessagesKey` key
+func (c *catalog) json(keys []string) ([]byte, error) {
+	values := map[string]any{}
+	for _, key := range keys {
+		entry := c.entries[key]
+
+		into := values
+		if entry.obsolete {
+			if _, ok := values[templateManager.ObsoleteMessagesKey]; !ok {
+				values[templateManager.ObsoleteMessagesKey] = map[string]any{}
+			}
+			into = values[templateManager.ObsoleteMessagesKey].(map[string]any)
+		}
+
+		var value any = entry.text
+		if entry.plural {
+			value = entry.plurals
+		}
+
+		if c.nested {
+			catalogNest(into, key, value)
+		} else {
+			into[key] = value
+		}
+	}
+
+	buffer := &bytes.Buffer{}
+	encoder := json.NewEncoder(buffer)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "\t")
+	err := encoder.Encode(values)
+
+	return buffer.Bytes(), err
+}
+
+// Adds a value to nested JSON values, splitting its key at each ".". Where part of the key is already used by a
+// message, the rest of the key is kept flat
+func catalogNest(values map[string]any, key string, value any) {
+	name, rest, ok := strings.Cut(key, ".")
+	for ok {
+		if _, found := values[name]; !found {
+			values[name] = map[string]any{}
+		}
+
+		nested, isMap := values[name].(map[string]any)
+		if !isMap {
+			break
+		}
+
+		values, key = nested, rest
+		name, rest, ok = strings.Cut(key, ".")
+	}
+
+	values[key] = value
+}
+
+// Formats the catalog as YAML (with flat keys), marking obsolete messages with a comment
+func (c *catalog) yaml(keys []string) []byte {
+	quote := func(text string) string {
+		quoted, _ := json.Marshal(text)
+		return string(quoted)
+	}
+
+	buffer := &bytes.Buffer{}
+	for _, key := range keys {
+		entry := c.entries[key]
+		if entry.obsolete {
+			buffer.WriteString("# obsolete\n")
+		}
+
+		if !entry.plural {
+			fmt.Fprintf(buffer, "%s: %s\n", quote(key), quote(entry.text))
+			continue
+		}
+
+		fmt.Fprintf(buffer, "%s:\n", quote(key))
+		for _, category := range pluralCategories {
+			if text, ok := entry.plurals[category]; ok {
+				fmt.Fprintf(buffer, "  %s: %s\n", category, quote(text))
+			}
+		}
+	}
+
+	return buffer.Bytes()
+}
+
+// Formats the catalog as a gettext .po file, with obsolete messages commented out (`#~`)
+func (c *catalog) po(keys []string, locale string) []byte {
+	if len(c.header) == 0 {
+		c.header = "Content-Type: text/plain; charset=UTF-8\nLanguage: " + locale + "\n"
+	}
+
+	buffer := &bytes.Buffer{}
+	buffer.WriteString("msgid \"\"\nmsgstr \"\"\n")
+	for _, line := range strings.SplitAfter(c.header, "\n") {
+		if len(line) > 0 {
+			buffer.WriteString(strconv.Quote(line) + "\n")
+		}
+	}
+
+	for _, key := range keys {
+		entry	:= c.entries[key]
+		prefix	:= ""
+		if entry.obsolete {
+			prefix = "#~ "
+		}
+
+		buffer.WriteString("\n")
+		for _, comment := range entry.comments {
+			buffer.WriteString(comment + "\n")
+		}
+		if !entry.obsolete && len(entry.references) > 0 {
+			buffer.WriteString("#: " + strings.Join(entry.references, " ") + "\n")
+		}
+		if len(entry.flags) > 0 {
+			buffer.WriteString("#, " + entry.flags + "\n")
+		}
+
+		id := key
+		if context, message, ok := strings.Cut(key, "|"); ok {
+			fmt.Fprintf(buffer, "%smsgctxt %s\n", prefix, strconv.Quote(context))
+			id = message
+		}
+		fmt.Fprintf(buffer, "%smsgid %s\n", prefix, strconv.Quote(id))
+
+		if !entry.plural {
+			fmt.Fprintf(buffer, "%smsgstr %s\n", prefix, strconv.Quote(entry.text))
+			continue
+		}
+
+		fmt.Fprintf(buffer, "%smsgid_plural %s\n", prefix, strconv.Quote(id))
+		for i, form := range entry.forms {
+			fmt.Fprintf(buffer, "%smsgstr[%d] %s\n", prefix, i, strconv.Quote(form))
+		}
+	}
+
+	return buffer.Bytes()
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	templateManager "github.com/paul-norman/go-template-manager"
+)
+
+// The messages extracted from the templates in every test
+var testMessages = []templateManager.ExtractedMessage{
+	{Key: "greeting", References: []string{"index.html:1"}},
+	{Key: "items", Plural: true, References: []string{"index.html:2"}},
+	{Key: "nav.about", References: []string{"layouts/main.html:3"}},
+	{Key: "nav.home", References: []string{"layouts/main.html:4"}},
+}
+
+// Writes a catalog into a temporary directory, updates it with `testMessages` and reads the result back
+func testUpdateCatalog(tester *testing.T, name string, content string, locale string, fill bool) (updateResult, string) {
+	path := filepath.Join(tester.TempDir(), name)
+	if len(content) > 0 {
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			tester.Fatal(err)
+		}
+	}
+
+	result, err := updateCatalog(path, locale, testMessages, fill, false)
+	if err != nil {
+		tester.Fatalf("\033[31mFAIL: \033[36mupdateCatalog(%s)\033[0m: %s", name, err.Error())
+	}
+
+	buffer, err := os.ReadFile(path)
+	if err != nil {
+		tester.Fatal(err)
+	}
+
+	return result, string(buffer)
+}
+
+// Compares a result with the expected value
+func testCatalogResult(tester *testing.T, name string, result any, expected any) {
+	if !reflect.DeepEqual(result, expected) {
+		tester.Errorf("\033[31mFAIL: \033[36m%s\033[0m:\n\t\033[31mProduced: \033[33m%#v\033[0m\n\t\033[31mExpected: \033[33m%#v\033[0m", name, result, expected)
+	}
+}
+
+func TestUpdateJsonCatalog(tester *testing.T) {
+	result, content := testUpdateCatalog(tester, "fr.json", `{
+	"greeting": "Bonjour",
+	"items": {"one": "{Count} article", "other": "{Count} articles"},
+	"nav": {"home": "Accueil", "old": "Ancien"},
+	"#obsolete": {"nav": {"older": "Plus ancien"}}
+}`, "fr", false)
+
+	testCatalogResult(tester, "json result", result, updateResult{added: []string{"nav.about"}, kept: 3, obsolete: []string{"nav.old", "nav.older"}})
+
+	var values map[string]any
+	err := json.Unmarshal([]byte(content), &values)
+	testCatalogResult(tester, "json error", err, nil)
+	testCatalogResult(tester, "json content", values, map[string]any{
+		"greeting":	"Bonjour",
+		"items":	map[string]any{"one": "{Count} article", "other": "{Count} articles"},
+		"nav":		map[string]any{"about": "", "home": "Accueil"},
+		"#obsolete":	map[string]any{"nav": map[string]any{"old": "Ancien", "older": "Plus ancien"}},
+	})
+
+	result, content = testUpdateCatalog(tester, "en.json", `{"greeting": "Hello", "nav.home": "Home", "unused": "Unused"}`, "en", true)
+
+	testCatalogResult(tester, "flat json result", result, updateResult{added: []string{"items", "nav.about"}, kept: 2, obsolete: []string{"unused"}})
+	testCatalogResult(tester, "flat json content", content, `{
+	"#obsolete": {
+		"unused": "Unused"
+	},
+	"greeting": "Hello",
+	"items": {
+		"one": "items",
+		"other": "items"
+	},
+	"nav.about": "nav.about",
+	"nav.home": "Home"
+}
+`)
+}
+
+func TestUpdateYamlCatalog(tester *testing.T) {
+	result, content := testUpdateCatalog(tester, "de.yaml", "greeting: Hallo\nnav:\n  home: Startseite\n# obsolete\nold: Alt\n", "de", false)
+
+	testCatalogResult(tester, "yaml result", result, updateResult{added: []string{"items", "nav.about"}, kept: 2, obsolete: []string{"old"}})
+	testCatalogResult(tester, "yaml content", content, `"greeting": "Hallo"
+"items":
+  one: ""
+  other: ""
+"nav.about": ""
+"nav.home": "Startseite"
+# obsolete
+"old": "Alt"
+`)
+}
+
+func TestUpdatePoCatalog(tester *testing.T) {
+	result, content := testUpdateCatalog(tester, "ru.po", `msgid ""
+msgstr ""
+"Language: ru\n"
+"Plural-Forms: nplurals=3; plural=(n%10==1 && n%100!=11 ? 0 : n%10>=2 && n%10<=4 && (n%100<10 || n%100>=20) ? 1 : 2);\n"
+
+# Shown on every page
+#: old.html:1
+#, fuzzy
+msgid "greeting"
+msgstr "Привет"
+
+msgid "items"
+msgstr "предмет"
+
+#~ msgid "nav.home"
+#~ msgstr "Главная"
+
+msgid "old"
+msgstr "Старый"
+`, "ru", false)
+
+	testCatalogResult(tester, "po result", result, updateResult{added: []string{"nav.about"}, kept: 3, obsolete: []string{"old"}})
+	testCatalogResult(tester, "po content", content, `msgid ""
+msgstr ""
+"Language: ru\n"
+"Plural-Forms: nplurals=3; plural=(n%10==1 && n%100!=11 ? 0 : n%10>=2 && n%10<=4 && (n%100<10 || n%100>=20) ? 1 : 2);\n"
+
+# Shown on every page
+#: index.html:1
+#, fuzzy
+msgid "greeting"
+msgstr "Привет"
+
+#: index.html:2
+msgid "items"
+msgid_plural "items"
+msgstr[0] "предмет"
+msgstr[1] ""
+msgstr[2] ""
+
+#: layouts/main.html:3
+msgid "nav.about"
+msgstr ""
+
+#: layouts/main.html:4
+msgid "nav.home"
+msgstr "Главная"
+
+#~ msgid "old"
+#~ msgstr "Старый"
+`)
+}
@@ -0,0 +1,125 @@
+/*
+Command tmextract keeps translation catalogs in sync with templates.
+
+It scans every template (including layouts, partials and components) for `t` / `tn` calls and `trans` blocks, then
+writes or merges a catalog for each locale. New messages are added (untranslated), existing translations are kept
+and messages which are no longer used are flagged as obsolete (or removed with `-prune`).
+
+Usage:
+
+	tmextract -templates templates -extensions .html -out translations -locales en,fr,de
+*/
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	templateManager "github.com/paul-norman/go-template-manager"
+)
+
+func main() {
+	templates	:= flag.String("templates", "templates", "the templates directory")
+	extensions	:= flag.String("extensions", ".html", "comma separated template file extensions")
+	left		:= flag.String("left", "{{", "the left template delimiter")
+	right		:= flag.String("right", "}}", "the right template delimiter")
+	out			:= flag.String("out", "translations", "the translations directory")
+	locales		:= flag.String("locales", "", "comma separated locales to update (default: every existing catalog)")
+	format		:= flag.String("format", "json", "the format of new catalogs: json, yaml or po")
+	source		:= flag.String("source", "", "the locale whose new messages are filled in with their keys")
+	prune		:= flag.Bool("prune", false, "remove obsolete messages rather than flagging them")
+	flag.Parse()
+
+	if *format != "json" && *format != "yaml" && *format != "po" {
+		exit(fmt.Errorf("invalid format %q: must be json, yaml or po", *format))
+	}
+
+	tm := templateManager.Init(*templates, strings.Split(*extensions, ",")...).Delimiters(*left, *right)
+	messages, err := tm.ExtractMessages()
+	if err != nil {
+		exit(err)
+	}
+
+	fmt.Printf("Found %d messages in %s\n", len(messages), *templates)
+
+	files, err := catalogFiles(*out)
+	if err != nil {
+		exit(err)
+	}
+
+	selected := []string{}
+	for _, locale := range strings.Split(*locales, ",") {
+		if locale = strings.TrimSpace(locale); len(locale) > 0 {
+			selected = append(selected, locale)
+		}
+	}
+	if len(selected) == 0 {
+		for locale := range files {
+			selected = append(selected, locale)
+		}
+		sort.Strings(selected)
+	}
+
+	if len(selected) == 0 {
+		exit(fmt.Errorf("no catalogs found in %s: choose the locales to create with -locales", *out))
+	}
+
+	for _, locale := range selected {
+		path, ok := files[locale]
+		if !ok {
+			path = filepath.Join(*out, locale + "." + *format)
+		}
+
+		result, err := updateCatalog(path, locale, messages, locale == *source, *prune)
+		if err != nil {
+			exit(fmt.Errorf("%s: %s", path, err.Error()))
+		}
+
+		fmt.Printf("%s: %d added, %d kept, %d obsolete", path, len(result.added), result.kept, len(result.obsolete))
+		if *prune {
+			fmt.Print(" (removed)")
+		}
+		fmt.Println()
+
+		for _, key := range result.obsolete {
+			fmt.Printf("\tobsolete: %s\n", key)
+		}
+	}
+}
+
+// Finds the existing single file catalogs in the translations directory, keyed by locale. Locales whose catalogs are
+// split across a directory are not updated
+func catalogFiles(directory string) (map[string]string, error) {
+	files := map[string]string{}
+
+	entries, err := os.ReadDir(directory)
+	if os.IsNotExist(err) {
+		return files, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() {
+			fmt.Printf("Skipping %s: catalogs split across a directory must be updated by hand\n", filepath.Join(directory, entry.Name()))
+			continue
+		}
+
+		extension := filepath.Ext(entry.Name())
+		if len(catalogFormat(extension)) > 0 {
+			files[strings.TrimSuffix(entry.Name(), extension)] = filepath.Join(directory, entry.Name())
+		}
+	}
+
+	return files, nil
+}
+
+// Prints an error and exits
+func exit(err error) {
+	fmt.Fprintln(os.Stderr, "tmextract: " + err.Error())
+	os.Exit(1)
+}
@@ -0,0 +1,189 @@
+package templateManager
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestAAComponentsSetup(tester  *testing.T) {
+	testsShowDetails	= true
+	testsShowSuccessful = false
+	consoleErrors		= false
+	consoleWarnings		= false
+	haltOnErrors		= false
+	haltOnWarnings		= false
+
+	testFormatTitle("components")
+}
+
+// Creates a `TemplateManager` for the `files` given (all within a "templates" directory)
+func testComponentsTemplateManager(files fstest.MapFS) *TemplateManager {
+	tm := Init("templates", ".html")
+	tm.fileSystem = http.FS(files)
+
+	return tm
+}
+
+// Renders the `name` template, collapsing all whitespace so that the output is easy to compare
+func testComponentsRender(tm *TemplateManager, name string, data any) string {
+	buf := &bytes.Buffer{}
+	err := tm.Render(name, data, buf)
+	if err != nil {
+		return "error: " + err.Error()
+	}
+
+	return strings.Join(strings.Fields(buf.String()), " ")
+}
+
+func TestComponentNamespaces(tester *testing.T) {
+	files := fstest.MapFS{
+		"templates/components/Card.html":			{Data: []byte(`[card {{ .Title }}]`)},
+		"templates/components/forms/Button.html":	{Data: []byte(`[form button {{ .Label }}]`)},
+		"templates/partials/Button.html":			{Data: []byte(`[partial button {{ .Label }}]`)},
+		"templates/index.html":						{Data: []byte(`<Card Title="a" /> <components:Card Title="b" /> <Components.Card Title="c" /> <forms:Button Label="d" /> <Forms.Button Label="e" /> <partials:Button Label="f" /> <Partials.Button Label="g" />`)},
+	}
+
+	tm := testComponentsTemplateManager(files).AddComponentDirectory("partials")
+	result := testComponentsRender(tm, "index.html", nil)
+
+	files["templates/bare.html"] = &fstest.MapFile{Data: []byte(`<Button Label="h" />`)}
+
+	ambiguous := ""
+	if err := testComponentsTemplateManager(files).AddComponentDirectory("partials").Parse(); err != nil {
+		ambiguous = err.Error()
+	}
+	delete(files, "templates/bare.html")
+
+	tests := []struct { inputs []any; result any; expected any }{
+		{[]any{"bare and namespaced tags"}, result, "[card a] [card b] [card c] [form button d] [form button e] [partial button f] [partial button g]"},
+		{[]any{"ambiguous bare tag"}, ambiguous, "bare.html: component <Button> is defined by both components/forms/Button.html and partials/Button.html, so it must be used with a namespace"},
+	}
+
+	testRunTests("componentNames", tests, tester)
+}
+
+func TestComponentTags(tester *testing.T) {
+	files := fstest.MapFS{
+		"templates/components/VideoEmbed.html":	{Data: []byte(`[video {{ .Id }}|{{ render .ComponentContent }}]`)},
+		"templates/components/Button.html":		{Data: []byte(`[button]`)},
+		"templates/self-closing.html":			{Data: []byte(`<VideoEmbed Id="a" /> <VideoEmbed Id="b">wrapped</VideoEmbed> <VideoEmbed Id="c"/>`)},
+		"templates/kebab.html":					{Data: []byte(`<video-embed Id="a" /> <components:video-embed Id="b" /> <button>native</button>`)},
+	}
+
+	tm := testComponentsTemplateManager(files)
+	kebab := testComponentsTemplateManager(files).KebabCaseComponents(true)
+
+	tests := []struct { inputs []any; result any; expected any }{
+		{[]any{"self-closing.html"}, testComponentsRender(tm, "self-closing.html", nil), "[video a|] [video b|wrapped] [video c|]"},
+		{[]any{"kebab.html", false}, testComponentsRender(tm, "kebab.html", nil), `<video-embed Id="a" /> <components:video-embed Id="b" /> <button>native</button>`},
+		{[]any{"kebab.html", true}, testComponentsRender(kebab, "kebab.html", nil), "[video a|] [video b|] <button>native</button>"},
+	}
+
+	testRunTests("componentTags", tests, tester)
+}
+
+func TestComponentAssets(tester *testing.T) {
+	files := fstest.MapFS{
+		"templates/layouts/main.html":			{Data: []byte(`<head>{{ componentStyles }}</head>{{ block "content" . }}{{ end }}<foot>{{ componentScripts }}</foot>`)},
+		"templates/components/Alert.html":		{Data: []byte(`{{ style }}<style>.alert-{{ .Level }}{{ if eq .Level "error" }}.loud{{ end }}{}</style>{{ end }}{{ script }}<script>alert()</script>{{ end }}[alert]`)},
+		"templates/components/Card.html":		{Data: []byte(`{{ style }}<style>.card{}</style>{{ end }}[card <Alert Level="info" />]`)},
+		"templates/components/Plain.html":		{Data: []byte(`[plain]`)},
+		"templates/repeated.html":				{Data: []byte(`{{ extends "layouts/main.html" }}{{ define "content" }}<Alert Level="error" /><Plain /><Alert Level="info" />{{ end }}`)},
+		"templates/nested.html":				{Data: []byte(`{{ extends "layouts/main.html" }}{{ define "content" }}<Card /><Card />{{ end }}`)},
+		"templates/hidden.html":				{Data: []byte(`{{ extends "layouts/main.html" }}{{ define "content" }}{{ if .Show }}<Alert Level="info" />{{ end }}<Plain />{{ end }}`)},
+	}
+
+	tm := testComponentsTemplateManager(files).ExcludeDirectory("layouts").Reload(true)
+
+	tests := []struct { inputs []any; result any; expected any }{
+		{[]any{"repeated.html"}, testComponentsRender(tm, "repeated.html", nil), "<head><style>.alert-error.loud{}</style></head>[alert][plain][alert]<foot><script>alert()</script></foot>"},
+		{[]any{"nested.html"}, testComponentsRender(tm, "nested.html", nil), "<head><style>.card{}</style> <style>.alert-info{}</style></head>[card [alert]][card [alert]]<foot><script>alert()</script></foot>"},
+		{[]any{"hidden.html"}, testComponentsRender(tm, "hidden.html", nil), "<head></head>[plain]<foot></foot>"},
+	}
+
+	files["templates/components/Alert.html"] = &fstest.MapFile{Data: []byte(`[alert]`)}
+	tests = append(tests, struct { inputs []any; result any; expected any }{[]any{"repeated.html", "reloaded"}, testComponentsRender(tm, "repeated.html", nil), "<head></head>[alert][plain][alert]<foot></foot>"})
+
+	written := testComponentsTemplateManager(files).ExcludeDirectory("layouts").Render("nested.html", nil, testFailingWriter{})
+	tests = append(tests, struct { inputs []any; result any; expected any }{[]any{"nested.html", "failing writer"}, written, errTestWrite})
+
+	testRunTests("componentAssets", tests, tester)
+}
+
+// The error returned by `testFailingWriter`
+var errTestWrite = errors.New("write failed")
+
+// A writer which always fails
+type testFailingWriter struct{}
+
+func (testFailingWriter) Write([]byte) (int, error) {
+	return 0, errTestWrite
+}
+
+func TestComponentInheritance(tester *testing.T) {
+	files := fstest.MapFS{
+		"templates/components/Greeting.html":	{Data: []byte(`[{{ with .Lang }}{{ . }}{{ end }} {{ with .User }}{{ . }}{{ end }} {{ with .Root }}{{ .Site }}{{ end }}{{ with .ComponentRoot }}leaked{{ end }}]`)},
+		"templates/components/Panel.html":		{Data: []byte(`[panel {{ .User }} <Greeting inherit="Root" />]`)},
+		"templates/attribute.html":				{Data: []byte(`<Greeting inherit="Lang, User" Lang="fr" />`)},
+		"templates/isolated.html":				{Data: []byte(`<Greeting />`)},
+		"templates/nested.html":				{Data: []byte(`{{ range .Users }}<Panel inherit="User" />{{ end }}`)},
+	}
+
+	data := Params{"Site": "site", "Lang": "en", "User": "ada", "Users": []Params{{"User": "bob"}, {"User": "cy"}}}
+
+	tm := testComponentsTemplateManager(files)
+	global := testComponentsTemplateManager(files).InheritComponentParams([]string{"User", "Root"})
+
+	tests := []struct { inputs []any; result any; expected any }{
+		{[]any{"attribute.html"}, testComponentsRender(tm, "attribute.html", data), "[fr ada ]"},
+		{[]any{"isolated.html"}, testComponentsRender(tm, "isolated.html", data), "[ ]"},
+		{[]any{"nested.html"}, testComponentsRender(tm, "nested.html", data), "[panel bob [ site]][panel cy [ site]]"},
+		{[]any{"isolated.html", "InheritComponentParams"}, testComponentsRender(global, "isolated.html", data), "[ ada site]"},
+	}
+
+	testRunTests("componentInherit", tests, tester)
+}
+
+func TestComponentGallery(tester *testing.T) {
+	files := fstest.MapFS{
+		"templates/components/Button.html":	{Data: []byte(`{{ examples }}[{"name": "Quoted", "attributes": {"Label": "Say \"hi\""}, "params": {"User": "ada"}}]{{ end }}[{{ .Label }} {{ .Site }} {{ .User }} {{ .Data.settings.Theme }}]`)},
+		"data/settings.json":				{Data: []byte(`{"Theme": "dark"}`)},
+		"templates/_params.json":			{Data: []byte(`{"User": "directory"}`)},
+	}
+
+	tm := testComponentsTemplateManager(files).DataDirectory("data").AddGlobalParam("Site", "site").InheritComponentParams([]string{"Site", "User", "Data"})
+	server := httptest.NewServer(tm.ComponentGallery())
+	defer server.Close()
+
+	body := func(url string) string {
+		response, err := http.Get(url)
+		if err != nil {
+			return err.Error()
+		}
+		defer response.Body.Close()
+
+		buf := &bytes.Buffer{}
+		buf.ReadFrom(response.Body)
+
+		return strconv.Itoa(response.StatusCode) + " " + buf.String()
+	}
+
+	index	:= body(server.URL)
+	button	:= body(server.URL + "?component=components/Button.html")
+	missing	:= body(server.URL + "?component=components/Missing.html")
+
+	tests := []struct { inputs []any; result any; expected any }{
+		{[]any{"/", "lists components"}, strings.HasPrefix(index, "200 ") && strings.Contains(index, `href="?component=components%2fButton.html"`), true},
+		{[]any{"Button.html", "markup"}, strings.Contains(button, `&lt;Button Label=&#34;Say &amp;#34;hi&amp;#34;&#34; /&gt;`), true},
+		{[]any{"Button.html", "output"}, strings.Contains(button, `<div class="preview">[Say &#34;hi&#34; site ada dark]</div>`), true},
+		{[]any{"Missing.html"}, strings.HasPrefix(missing, "404 "), true},
+	}
+
+	testRunTests("ComponentGallery", tests, tester)
+}
@@ -0,0 +1,145 @@
+package templateManager
+
+/*
+Functions dedicated to loading the data directory (JSON, YAML, TOML and CSV files) into the `.Data` variable
+*/
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strings"
+
+	"github.com/paul-norman/go-template-manager/fsWalk"
+)
+
+// Reads every data file within the data directory into a nested map, keyed by subdirectory and then by filename
+// (without its extension), so `data/nav/main.yaml` becomes `.Data.nav.main`
+func (tm *TemplateManager) loadData() error {
+	data := map[string]any{}
+
+	if len(tm.dataDirectory) == 0 {
+		tm.data = data
+		return nil
+	}
+
+	walk := func(path string, info fs.DirEntry, err error) error {
+		if err != nil || info == nil {
+			if path == tm.dataDirectory && errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+
+		if info.IsDir() {
+			return nil
+		}
+
+		format := dataFormat(path)
+		if len(format) == 0 {
+			return nil
+		}
+
+		name, _ := cleanPath(path, tm.dataDirectory)
+
+		buffer, err := fsWalk.ReadFile(path, tm.fileSystem)
+		if err != nil {
+			return err
+		}
+
+		value, err := parseDataFile(format, buffer)
+		if err != nil {
+			return fmt.Errorf("%s: invalid %s data: %s", path, format, err.Error())
+		}
+
+		return addDataValue(data, strings.Split(strings.TrimSuffix(name, filepath.Ext(name)), "/"), value, path)
+	}
+
+	var err error
+	if tm.fileSystem != nil {
+		err = fsWalk.WalkDir(tm.fileSystem, tm.dataDirectory, walk)
+	} else {
+		err = filepath.WalkDir(tm.dataDirectory, walk)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	tm.data = data
+
+	return nil
+}
+
+// Determines the format of a data file from its extension (empty if it is not a data file)
+func dataFormat(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+		case ".json":
+			return "json"
+		case ".yaml", ".yml":
+			return "yaml"
+		case ".toml":
+			return "toml"
+		case ".csv":
+			return "csv"
+	}
+
+	return ""
+}
+
+// Parses the contents of a data file into typed values.
+// CSV files use their first row as a header, becoming a slice of maps (one per row) keyed by the header names
+func parseDataFile(format string, buffer []byte) (any, error) {
+	if format == "csv" {
+		records, err := csv.NewReader(strings.NewReader(string(buffer))).ReadAll()
+		if err != nil {
+			return nil, err
+		}
+
+		rows := []map[string]string{}
+		for i := 1; i < len(records); i++ {
+			row := map[string]string{}
+			for j, column := range records[0] {
+				row[column] = records[i][j]
+			}
+			rows = append(rows, row)
+		}
+
+		return rows, nil
+	}
+
+	var value any
+	err := decodeDocument(format, buffer, &value)
+	if err != nil {
+		return nil, err
+	}
+
+	return normaliseDecodedValue(value), nil
+}
+
+// Places a value into the nested data map at the position given by its path segments
+func addDataValue(data map[string]any, segments []string, value any, path string) error {
+	for _, segment := range segments[:len(segments) - 1] {
+		existing, ok := data[segment]
+		if !ok {
+			existing = map[string]any{}
+			data[segment] = existing
+		}
+
+		nested, ok := existing.(map[string]any)
+		if !ok {
+			return fmt.Errorf("%s: data %q is already defined by another file", path, segment)
+		}
+		data = nested
+	}
+
+	name := segments[len(segments) - 1]
+	if _, ok := data[name]; ok {
+		return fmt.Errorf("%s: data %q is already defined by another file or directory", path, name)
+	}
+	data[name] = value
+
+	return nil
+}
@@ -0,0 +1,358 @@
+package templateManager
+
+/*
+Functions dedicated to parsing dates (absolute or relative, e.g. "+1 week") and calculating with them
+*/
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The layouts tried (in order) when parsing a date string. Layouts without a zone are parsed in the local timezone
+var dateParseLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05Z0700",
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04:05Z07:00",
+	"2006-01-02 15:04:05 -0700",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+	"20060102T150405Z0700",
+	"20060102T150405",
+	"20060102",
+	time.RFC1123Z,
+	time.RFC1123,
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"Mon, 2 Jan 2006 15:04:05 MST",
+	time.RFC850,
+	time.RFC822Z,
+	time.RFC822,
+	time.RubyDate,
+	time.UnixDate,
+	time.ANSIC,
+	"2 January 2006 15:04",
+	"2 January 2006",
+	"2 Jan 2006",
+	"January 2, 2006 15:04",
+	"January 2, 2006",
+	"Jan 2, 2006",
+	"01/02/2006 15:04",
+	"01/02/2006",
+	"02-01-2006",
+	"02.01.2006",
+}
+
+// The units that dates may be moved by or truncated to, keyed by every accepted spelling
+var dateUnits = map[string]string{
+	"sec": "second", "secs": "second", "second": "second", "seconds": "second",
+	"min": "minute", "mins": "minute", "minute": "minute", "minutes": "minute",
+	"hour": "hour", "hours": "hour",
+	"day": "day", "days": "day",
+	"week": "week", "weeks": "week",
+	"fortnight": "fortnight", "fortnights": "fortnight",
+	"month": "month", "months": "month",
+	"quarter": "quarter", "quarters": "quarter",
+	"year": "year", "years": "year",
+}
+
+// The names of the days of the week, keyed by every accepted spelling
+var dateWeekdays = map[string]time.Weekday{
+	"sunday": time.Sunday, "sun": time.Sunday,
+	"monday": time.Monday, "mon": time.Monday,
+	"tuesday": time.Tuesday, "tue": time.Tuesday, "tues": time.Tuesday,
+	"wednesday": time.Wednesday, "wed": time.Wednesday,
+	"thursday": time.Thursday, "thu": time.Thursday, "thurs": time.Thursday,
+	"friday": time.Friday, "fri": time.Friday,
+	"saturday": time.Saturday, "sat": time.Saturday,
+}
+
+// Finds the canonical name of a unit (e.g. "mins" -> "minute")
+func dateUnit(unit string) (string, error) {
+	if canonical, ok := dateUnits[strings.ToLower(strings.TrimSpace(unit))]; ok {
+		return canonical, nil
+	}
+
+	return "", fmt.Errorf("unknown unit %q", unit)
+}
+
+// Moves a time by a number of units. Calendar units follow `time.AddDate` (so 31st January + 1 month is 2nd March)
+func addDateUnit(t time.Time, unit string, amount int) time.Time {
+	switch unit {
+		case "second":
+			return t.Add(time.Duration(amount) * time.Second)
+		case "minute":
+			return t.Add(time.Duration(amount) * time.Minute)
+		case "hour":
+			return t.Add(time.Duration(amount) * time.Hour)
+		case "day":
+			return t.AddDate(0, 0, amount)
+		case "week":
+			return t.AddDate(0, 0, amount * 7)
+		case "fortnight":
+			return t.AddDate(0, 0, amount * 14)
+		case "month":
+			return t.AddDate(0, amount, 0)
+		case "quarter":
+			return t.AddDate(0, amount * 3, 0)
+	}
+
+	return t.AddDate(amount, 0, 0)
+}
+
+// Truncates a time to the start of a unit (weeks start on Monday)
+func startOfDate(t time.Time, unit string) (time.Time, error) {
+	unit, err := dateUnit(unit)
+	if err != nil {
+		return t, err
+	}
+
+	year, month, day := t.Date()
+	hour, minute, second := t.Clock()
+	switch unit {
+		case "second":
+			return time.Date(year, month, day, hour, minute, second, 0, t.Location()), nil
+		case "minute":
+			return time.Date(year, month, day, hour, minute, 0, 0, t.Location()), nil
+		case "hour":
+			return time.Date(year, month, day, hour, 0, 0, 0, t.Location()), nil
+		case "day":
+			return time.Date(year, month, day, 0, 0, 0, 0, t.Location()), nil
+		case "week":
+			return time.Date(year, month, day - dateISOWeekday(t) + 1, 0, 0, 0, 0, t.Location()), nil
+		case "month":
+			return time.Date(year, month, 1, 0, 0, 0, 0, t.Location()), nil
+		case "quarter":
+			return time.Date(year, (month - 1) / 3 * 3 + 1, 1, 0, 0, 0, 0, t.Location()), nil
+		case "year":
+			return time.Date(year, 1, 1, 0, 0, 0, 0, t.Location()), nil
+	}
+
+	return t, fmt.Errorf("can't find the start of a %s", unit)
+}
+
+// Finds the last moment of a unit (weeks end on Sunday)
+func endOfDate(t time.Time, unit string) (time.Time, error) {
+	start, err := startOfDate(t, unit)
+	if err != nil {
+		return t, err
+	}
+	unit, _ = dateUnit(unit)
+
+	return addDateUnit(start, unit, 1).Add(-time.Nanosecond), nil
+}
+
+// Counts the whole units between two times (negative if `to` is before `from`)
+func dateDifference(from time.Time, to time.Time, unit string) (int, error) {
+	unit, err := dateUnit(unit)
+	if err != nil {
+		return 0, err
+	}
+
+	switch unit {
+		case "second":
+			return int(to.Sub(from) / time.Second), nil
+		case "minute":
+			return int(to.Sub(from) / time.Minute), nil
+		case "hour":
+			return int(to.Sub(from) / time.Hour), nil
+		case "day":
+			return int(to.Sub(from) / (24 * time.Hour)), nil
+		case "week":
+			return int(to.Sub(from) / (7 * 24 * time.Hour)), nil
+		case "fortnight":
+			return int(to.Sub(from) / (14 * 24 * time.Hour)), nil
+	}
+
+	fromYear, fromMonth, _ := from.Date()
+	toYear, toMonth, _ := to.Date()
+	months := (toYear - fromYear) * 12 + int(toMonth - fromMonth)
+	if months > 0 && from.AddDate(0, months, 0).After(to) {
+		months--
+	} else if months < 0 && from.AddDate(0, months, 0).Before(to) {
+		months++
+	}
+
+	switch unit {
+		case "quarter":
+			return months / 3, nil
+		case "year":
+			return months / 12, nil
+	}
+
+	return months, nil
+}
+
+// Parses a date with one of `dateParseLayouts` (or a "@" prefixed Unix time)
+func parseAbsoluteDate(value string) (time.Time, bool) {
+	if strings.HasPrefix(value, "@") {
+		if seconds, err := strconv.ParseInt(value[1:], 10, 64); err == nil {
+			return time.Unix(seconds, 0).In(dateLocalTimezone), true
+		}
+	}
+
+	for _, layout := range dateParseLayouts {
+		if t, err := time.ParseInLocation(layout, value, dateLocalTimezone); err == nil {
+			return t, true
+		}
+	}
+
+	return time.Time{}, false
+}
+
+/*
+Parses a date string relative to `base`. The string may be an absolute date (see `dateParseLayouts`), a relative
+phrase (e.g. "+1 week 2 days", "3 hours ago", "next monday", "first day of next month", "tomorrow noon") or an absolute
+date followed by a relative phrase (e.g. "2020-01-31 +1 day")
+*/
+func parseDate(value string, base time.Time) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if t, ok := parseAbsoluteDate(value); ok {
+		return t, nil
+	}
+
+	fields := strings.Fields(value)
+	for i := len(fields) - 1; i > 0; i-- {
+		if t, ok := parseAbsoluteDate(strings.Join(fields[:i], " ")); ok {
+			return relativeDate(strings.Join(fields[i:], " "), t, 1)
+		}
+	}
+
+	return relativeDate(value, base, 1)
+}
+
+/*
+Applies a relative phrase to `base`, multiplying each of its offsets by `sign`. Offsets are applied first, then
+weekdays ("next monday") and lastly "first / last day of"
+*/
+func relativeDate(phrase string, base time.Time, sign int) (time.Time, error) {
+	phrase = strings.ToLower(phrase)
+	if rest := strings.Trim(regexps["findRelativeDate"].ReplaceAllString(phrase, ""), " ,"); len(rest) > 0 {
+		return base, fmt.Errorf("can't understand %q in date %q", rest, phrase)
+	}
+
+	type offset struct{ unit string; amount int }
+	offsets		:= []offset{}
+	weekday		:= -1
+	direction	:= 0
+	dayOf		:= ""
+	t			:= base
+
+	words := regexps["findRelativeDate"].FindAllStringSubmatch(phrase, -1)
+	for i := 0; i < len(words); i++ {
+		number, unit, word := words[i][1], words[i][2], words[i][3]
+		if len(number) > 0 {
+			canonical, err := dateUnit(unit)
+			if err != nil {
+				return base, err
+			}
+			amount, _ := strconv.Atoi(strings.ReplaceAll(strings.TrimPrefix(number, "+"), " ", ""))
+			offsets = append(offsets, offset{canonical, amount})
+			continue
+		}
+
+		next := func(n int) string {
+			if i + n < len(words) {
+				return words[i + n][3]
+			}
+			return ""
+		}
+
+		switch word {
+			case "now":
+			case "today", "midnight":
+				t, _ = startOfDate(t, "day")
+			case "noon":
+				t, _ = startOfDate(t, "day")
+				t = t.Add(12 * time.Hour)
+			case "tomorrow":
+				t, _ = startOfDate(t.AddDate(0, 0, 1), "day")
+			case "yesterday":
+				t, _ = startOfDate(t.AddDate(0, 0, -1), "day")
+			case "ago":
+				for j := range offsets {
+					offsets[j].amount = -offsets[j].amount
+				}
+			case "first", "last", "next", "previous", "this":
+				if (word == "first" || word == "last") && next(1) == "day" && next(2) == "of" {
+					dayOf = word
+					i += 2
+					continue
+				}
+
+				amount := map[string]int{"next": 1, "last": -1, "previous": -1, "this": 0}[word]
+				if day, ok := dateWeekdays[next(1)]; ok {
+					weekday, direction = int(day), amount
+					i++
+					continue
+				}
+				canonical, err := dateUnit(next(1))
+				if word == "first" || err != nil {
+					return base, fmt.Errorf("can't understand %q in date %q", word, phrase)
+				}
+				offsets = append(offsets, offset{canonical, amount})
+				i++
+			default:
+				if day, ok := dateWeekdays[word]; ok {
+					weekday, direction = int(day), 0
+					continue
+				}
+				canonical, err := dateUnit(word)
+				if err != nil {
+					return base, fmt.Errorf("can't understand %q in date %q", word, phrase)
+				}
+				offsets = append(offsets, offset{canonical, 1})
+		}
+	}
+
+	if len(dayOf) > 0 {
+		year, month, _ := t.Date()
+		hour, minute, second := t.Clock()
+		t = time.Date(year, month, 1, hour, minute, second, t.Nanosecond(), t.Location())
+	}
+
+	for _, o := range offsets {
+		t = addDateUnit(t, o.unit, o.amount * sign)
+	}
+
+	if weekday >= 0 {
+		days := (weekday - int(t.Weekday()) + 7) % 7
+		switch {
+			case direction > 0 && days == 0:
+				days = 7
+			case direction < 0:
+				days -= 7
+		}
+		t, _ = startOfDate(t.AddDate(0, 0, days), "day")
+	}
+
+	switch dayOf {
+		case "first":
+			year, month, _ := t.Date()
+			t = time.Date(year, month, 1, 0, 0, 0, 0, t.Location())
+		case "last":
+			year, month, _ := t.Date()
+			t = time.Date(year, month + 1, 0, 0, 0, 0, 0, t.Location())
+	}
+
+	return t, nil
+}
+
+// Reads a time given as a `time.Time`, a Unix time or a date string (see `parseDate`)
+func dateValue(value any) (time.Time, error) {
+	switch val := value.(type) {
+		case time.Time:
+			return val, nil
+		case string:
+			return parseDate(val, time.Now().In(dateLocalTimezone))
+		case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+			num, _ := interfaceHelperConvertToInt64(val)
+			return time.Unix(num, 0).In(dateLocalTimezone), nil
+	}
+
+	return time.Time{}, fmt.Errorf("can't convert type %T to a time", value)
+}
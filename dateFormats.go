@@ -0,0 +1,361 @@
+package templateManager
+
+/*
+Functions dedicated to formatting dates using PHP `date()` and C `strftime()` (Python) format tokens
+*/
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// A format token: its Go layout (used to parse dates, "" if Go has no equivalent) and how it is formatted
+type dateToken struct {
+	layout		string
+	format		func(t time.Time, names dateNames) string
+	dayOfMonth	bool
+}
+
+// A part of a parsed date format: either a token or literal text
+type datePart struct {
+	token	*dateToken
+	source	string
+	flag	byte
+}
+
+// Formats a number with leading zeros
+func datePad(number int, width int) string {
+	return fmt.Sprintf("%0*d", width, number)
+}
+
+// A token that formats a number with leading zeros
+func dateNumber(layout string, width int, number func(t time.Time) int) dateToken {
+	return dateToken{layout: layout, format: func(t time.Time, _ dateNames) string {
+		return datePad(number(t), width)
+	}}
+}
+
+// A token that is always formatted with a Go layout (e.g. machine readable formats, which are never localised)
+func dateLayout(layout string) dateToken {
+	return dateToken{layout: layout, format: func(t time.Time, _ dateNames) string {
+		return t.Format(layout)
+	}}
+}
+
+// The AM / PM marker of a time
+func dateMeridiem(t time.Time, names dateNames) string {
+	if t.Hour() >= 12 {
+		return names.pm
+	}
+
+	return names.am
+}
+
+// The hour of a time on a 12 hour clock
+func dateHour12(t time.Time) int {
+	if hour := t.Hour() % 12; hour > 0 {
+		return hour
+	}
+
+	return 12
+}
+
+// The ISO 8601 day of the week (Monday is 1, Sunday is 7)
+func dateISOWeekday(t time.Time) int {
+	if t.Weekday() == time.Sunday {
+		return 7
+	}
+
+	return int(t.Weekday())
+}
+
+// The number of days in the month of a time
+func dateDaysInMonth(t time.Time) int {
+	return time.Date(t.Year(), t.Month() + 1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+// The year of a time, with at least 4 digits, a "-" before years BCE and (if `plus`) a "+" before years after 9999
+func dateExpandedYear(t time.Time, plus bool) string {
+	switch year := t.Year(); {
+		case year < 0:
+			return "-" + datePad(-year, 4)
+		case year > 9999 && plus:
+			return "+" + strconv.Itoa(year)
+		default:
+			return datePad(year, 4)
+	}
+}
+
+// The time zone offset of a time in seconds
+func dateOffset(t time.Time) int {
+	_, offset := t.Zone()
+
+	return offset
+}
+
+// The tokens of PHP's `date()` function
+var phpDateTokens = map[string]dateToken{
+	// Day
+	"d": {layout: "02", format: func(t time.Time, _ dateNames) string { return datePad(t.Day(), 2) }, dayOfMonth: true},
+	"D": {layout: "Mon", format: func(t time.Time, names dateNames) string { return names.daysShort[t.Weekday()] }},
+	"j": {layout: "2", format: func(t time.Time, _ dateNames) string { return strconv.Itoa(t.Day()) }, dayOfMonth: true},
+	"l": {layout: "Monday", format: func(t time.Time, names dateNames) string { return names.days[t.Weekday()] }},
+	"N": dateNumber("", 1, dateISOWeekday),
+	"S": {format: func(t time.Time, names dateNames) string { return names.ordinal(t.Day()) }},
+	"w": dateNumber("", 1, func(t time.Time) int { return int(t.Weekday()) }),
+	"z": dateNumber("", 1, func(t time.Time) int { return t.YearDay() - 1 }),
+	// Week
+	"W": dateNumber("", 2, func(t time.Time) int { _, week := t.ISOWeek(); return week }),
+	// Month
+	"F": {layout: "January", format: func(t time.Time, names dateNames) string { return names.months[t.Month() - 1] }},
+	"m": dateNumber("01", 2, func(t time.Time) int { return int(t.Month()) }),
+	"M": {layout: "Jan", format: func(t time.Time, names dateNames) string { return names.monthsShort[t.Month() - 1] }},
+	"n": dateNumber("1", 1, func(t time.Time) int { return int(t.Month()) }),
+	"t": dateNumber("", 2, dateDaysInMonth),
+	// Year
+	"L": {format: func(t time.Time, _ dateNames) string {
+		if dateDaysInMonth(time.Date(t.Year(), time.February, 1, 0, 0, 0, 0, time.UTC)) == 29 {
+			return "1"
+		}
+		return "0"
+	}},
+	"o": dateNumber("", 4, func(t time.Time) int { year, _ := t.ISOWeek(); return year }),
+	"X": {layout: "2006", format: func(t time.Time, _ dateNames) string { return dateExpandedYear(t, true) }},
+	"x": {layout: "2006", format: func(t time.Time, _ dateNames) string { return dateExpandedYear(t, false) }},
+	"Y": dateNumber("2006", 4, time.Time.Year),
+	"y": dateNumber("06", 2, func(t time.Time) int { return t.Year() % 100 }),
+	// Time
+	"a": {layout: "pm", format: func(t time.Time, names dateNames) string { return strings.ToLower(dateMeridiem(t, names)) }},
+	"A": {layout: "PM", format: dateMeridiem},
+	"B": dateNumber("", 3, func(t time.Time) int { return (t.UTC().Hour() * 3600 + t.UTC().Minute() * 60 + t.UTC().Second() + 3600) % 86400 * 10 / 864 }),
+	"g": dateNumber("3", 1, dateHour12),
+	"G": dateNumber("", 1, time.Time.Hour),
+	"h": dateNumber("03", 2, dateHour12),
+	"H": dateNumber("15", 2, time.Time.Hour),
+	"i": dateNumber("04", 2, time.Time.Minute),
+	"s": dateNumber("05", 2, time.Time.Second),
+	"u": dateNumber("000000", 6, func(t time.Time) int { return t.Nanosecond() / 1000 }),
+	"v": dateNumber("000", 3, func(t time.Time) int { return t.Nanosecond() / 1000000 }),
+	// Time zone
+	"e": {format: func(t time.Time, _ dateNames) string { return t.Location().String() }},
+	"I": {format: func(t time.Time, _ dateNames) string {
+		if t.IsDST() {
+			return "1"
+		}
+		return "0"
+	}},
+	"O": dateLayout("-0700"),
+	"P": dateLayout("-07:00"),
+	"p": dateLayout("Z07:00"),
+	"T": dateLayout("MST"),
+	"Z": dateNumber("", 1, dateOffset),
+	// Full date / time
+	"c": dateLayout("2006-01-02T15:04:05-07:00"),
+	"r": dateLayout("Mon, 02 Jan 2006 15:04:05 -0700"),
+	"U": {format: func(t time.Time, _ dateNames) string { return strconv.FormatInt(t.Unix(), 10) }},
+}
+
+// The tokens of C's `strftime()` function (and Python's `%f` microseconds)
+var strftimeTokens = map[string]dateToken{
+	"a": phpDateTokens["D"],
+	"A": phpDateTokens["l"],
+	"b": phpDateTokens["M"],
+	"B": phpDateTokens["F"],
+	"c": {layout: "Mon Jan _2 15:04:05 2006", format: func(t time.Time, names dateNames) string {
+		return names.daysShort[t.Weekday()] + " " + names.monthsShort[t.Month() - 1] + " " + fmt.Sprintf("%2d", t.Day()) + " " + t.Format("15:04:05 2006")
+	}, dayOfMonth: true},
+	"C": dateNumber("", 2, func(t time.Time) int { return t.Year() / 100 }),
+	"d": phpDateTokens["d"],
+	"D": dateLayout("01/02/06"),
+	"e": {layout: "_2", format: func(t time.Time, _ dateNames) string { return fmt.Sprintf("%2d", t.Day()) }, dayOfMonth: true},
+	"f": phpDateTokens["u"],
+	"F": dateLayout("2006-01-02"),
+	"g": dateNumber("", 2, func(t time.Time) int { year, _ := t.ISOWeek(); return year % 100 }),
+	"G": phpDateTokens["o"],
+	"h": phpDateTokens["M"],
+	"H": phpDateTokens["H"],
+	"I": phpDateTokens["h"],
+	"j": dateNumber("002", 3, time.Time.YearDay),
+	"k": {format: func(t time.Time, _ dateNames) string { return fmt.Sprintf("%2d", t.Hour()) }},
+	"l": {format: func(t time.Time, _ dateNames) string { return fmt.Sprintf("%2d", dateHour12(t)) }},
+	"m": phpDateTokens["m"],
+	"M": phpDateTokens["i"],
+	"n": {layout: "\n", format: func(time.Time, dateNames) string { return "\n" }},
+	"p": phpDateTokens["A"],
+	"P": phpDateTokens["a"],
+	"r": {layout: "03:04:05 PM", format: func(t time.Time, names dateNames) string { return t.Format("03:04:05 ") + dateMeridiem(t, names) }},
+	"R": dateLayout("15:04"),
+	"s": phpDateTokens["U"],
+	"S": phpDateTokens["s"],
+	"t": {layout: "\t", format: func(time.Time, dateNames) string { return "\t" }},
+	"T": dateLayout("15:04:05"),
+	"u": phpDateTokens["N"],
+	"U": dateNumber("", 2, func(t time.Time) int { return (t.YearDay() + 6 - int(t.Weekday())) / 7 }),
+	"V": phpDateTokens["W"],
+	"w": phpDateTokens["w"],
+	"W": dateNumber("", 2, func(t time.Time) int { return (t.YearDay() + 6 - (int(t.Weekday()) + 6) % 7) / 7 }),
+	"x": dateLayout("01/02/06"),
+	"X": dateLayout("15:04:05"),
+	"y": phpDateTokens["y"],
+	"Y": phpDateTokens["Y"],
+	"z": phpDateTokens["O"],
+	":z": phpDateTokens["P"],
+	"Z": phpDateTokens["T"],
+	"%": {layout: "%", format: func(time.Time, dateNames) string { return "%" }},
+}
+
+// Predefined formats which may be used in place of (or within) PHP formats
+var dateNamedFormats = map[string]dateToken{
+	"ISO8601Z":	dateLayout("2006-01-02T15:04:05Z07:00"),
+	"ISO8601":	dateLayout("2006-01-02T15:04:05-07:00"),
+	"RFC822Z":	dateLayout("Mon, 02 Jan 06 15:04:05 -07:00"),
+	"RFC822":	dateLayout("Mon, 02 Jan 06 15:04:05 MST"),
+	"RFC850":	dateLayout("Monday, 02-Jan-06 15:04:05 MST"),
+	"RFC1036":	dateLayout("Mon, 02 Jan 06 15:04:05 -07:00"),
+	"RFC1123Z":	dateLayout("Mon, 02 Jan 2006 15:04:05 -07:00"),
+	"RFC1123":	dateLayout("Mon, 02 Jan 2006 15:04:05 MST"),
+	"RFC2822":	dateLayout("Mon, 02 Jan 2006 15:04:05 -07:00"),
+	"RFC3339":	dateLayout("2006-01-02T15:04:05Z07:00"),
+	"W3C":		dateLayout("2006-01-02T15:04:05Z07:00"),
+	"ATOM":		dateLayout("2006-01-02T15:04:05Z07:00"),
+	"COOKIE":	dateLayout("Monday, 02-Jan-2006 15:04:05 MST"),
+	"RSS":		dateLayout("Mon, 02 Jan 2006 15:04:05 -07:00"),
+	"MYSQL":	dateLayout("2006-01-02 15:04:05"),
+	"UNIX":		dateLayout("Mon Jan _2 15:04:05 MST 2006"),
+	"RUBY":		dateLayout("Mon Jan 02 15:04:05 -0700 2006"),
+	"ANSIC":	dateLayout("Mon Jan _2 15:04:05 2006"),
+}
+
+// The names of the predefined formats, longest first (so that "RFC822Z" is matched before "RFC822")
+var dateNamedFormatNames = func() []string {
+	names := []string{}
+	for name := range dateNamedFormats {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if len(names[i]) != len(names[j]) {
+			return len(names[i]) > len(names[j])
+		}
+		return names[i] < names[j]
+	})
+
+	return names
+}()
+
+// Reports whether a format is a Go layout rather than a PHP or strftime format
+func dateFormatIsLayout(format string) bool {
+	return strings.Contains(format, "06")
+}
+
+// Splits a PHP or strftime (if it contains a "%") format into its tokens and literal text. In PHP formats any
+// character may be escaped with a backslash, and in strftime formats a "-" flag removes a token's padding (`%-d`)
+func dateFormatParts(format string) []datePart {
+	parts := []datePart{}
+	literal := func(text string) {
+		if len(parts) > 0 && parts[len(parts) - 1].token == nil {
+			parts[len(parts) - 1].source += text
+		} else {
+			parts = append(parts, datePart{source: text})
+		}
+	}
+
+	if strings.Contains(format, "%") {
+		for i := 0; i < len(format); i++ {
+			if format[i] != '%' || i + 1 >= len(format) {
+				literal(format[i:i + 1])
+				continue
+			}
+
+			j, flag := i + 1, byte(0)
+			if format[j] == '-' && j + 1 < len(format) {
+				j, flag = j + 1, '-'
+			}
+
+			name := format[j:j + 1]
+			if name == ":" && j + 1 < len(format) {
+				name = format[j:j + 2]
+			}
+
+			if token, ok := strftimeTokens[name]; ok {
+				parts = append(parts, datePart{token: &token, source: format[i:j + len(name)], flag: flag})
+				i = j + len(name) - 1
+			} else {
+				literal(format[i:j + 1])
+				i = j
+			}
+		}
+
+		return parts
+	}
+
+	for i := 0; i < len(format); i++ {
+		if format[i] == '\\' && i + 1 < len(format) {
+			_, size := utf8.DecodeRuneInString(format[i + 1:])
+			literal(format[i + 1:i + 1 + size])
+			i += size
+			continue
+		}
+
+		named := ""
+		for _, name := range dateNamedFormatNames {
+			if strings.HasPrefix(format[i:], name) {
+				named = name
+				break
+			}
+		}
+		if len(named) > 0 {
+			token := dateNamedFormats[named]
+			parts = append(parts, datePart{token: &token, source: named})
+			i += len(named) - 1
+			continue
+		}
+
+		if token, ok := phpDateTokens[format[i:i + 1]]; ok {
+			parts = append(parts, datePart{token: &token, source: format[i:i + 1]})
+		} else {
+			literal(format[i:i + 1])
+		}
+	}
+
+	return parts
+}
+
+// Formats a time using a Go layout, a PHP format or a strftime format, with the month and day names, AM / PM markers
+// and ordinal suffixes of a locale ("" for English). Months use their genitive form (where a language has one) when
+// the day of the month is also shown
+func formatDate(t time.Time, format string, locale string) string {
+	if dateFormatIsLayout(format) {
+		return formatTimeLocale(t, format, locale)
+	}
+
+	parts := dateFormatParts(format)
+	names := dateLocale(locale)
+	for _, part := range parts {
+		if part.token != nil && part.token.dayOfMonth && len(names.monthsGenitive[0]) > 0 {
+			names.months = names.monthsGenitive
+			break
+		}
+	}
+
+	output := strings.Builder{}
+	for _, part := range parts {
+		if part.token == nil {
+			output.WriteString(part.source)
+			continue
+		}
+
+		text := part.token.format(t, names)
+		if part.flag == '-' && len(strings.TrimLeft(text, "0 ")) > 0 {
+			text = strings.TrimLeft(text, "0 ")
+		} else if part.flag == '-' {
+			text = "0"
+		}
+		output.WriteString(text)
+	}
+
+	return output.String()
+}
@@ -0,0 +1,319 @@
+package templateManager
+
+/*
+Functions dedicated to localising dates: month and day names, AM / PM markers and ordinal suffixes
+*/
+
+import (
+	"reflect"
+	"strings"
+	"time"
+)
+
+// The names used to format dates in a locale (days start on Sunday, as with `time.Weekday`)
+type dateNames struct {
+	months			[12]string
+	monthsShort		[12]string
+	monthsGenitive	[12]string
+	days			[7]string
+	daysShort		[7]string
+	am				string
+	pm				string
+	ordinal			func(day int) string
+}
+
+// A locale passed to a date function by the `locale` function, which takes priority over the render's locale
+type localeArgument string
+
+// A suffix that is the same for every day (e.g. "5." in German)
+func dateOrdinalSuffix(suffix string) func(int) string {
+	return func(int) string {
+		return suffix
+	}
+}
+
+// The date names of each supported locale (others use their language's names, or English)
+var dateLocales = map[string]dateNames{
+	"en": {
+		months:			[12]string{"January", "February", "March", "April", "May", "June", "July", "August", "September", "October", "November", "December"},
+		monthsShort:	[12]string{"Jan", "Feb", "Mar", "Apr", "May", "Jun", "Jul", "Aug", "Sep", "Oct", "Nov", "Dec"},
+		days:			[7]string{"Sunday", "Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday"},
+		daysShort:		[7]string{"Sun", "Mon", "Tue", "Wed", "Thu", "Fri", "Sat"},
+		am:				"AM",
+		pm:				"PM",
+		ordinal:		func(day int) string {
+			switch {
+				case day % 10 == 1 && day % 100 != 11: return "st"
+				case day % 10 == 2 && day % 100 != 12: return "nd"
+				case day % 10 == 3 && day % 100 != 13: return "rd"
+			}
+			return "th"
+		},
+	},
+	"cs": {
+		months:			[12]string{"leden", "únor", "březen", "duben", "květen", "červen", "červenec", "srpen", "září", "říjen", "listopad", "prosinec"},
+		monthsGenitive:	[12]string{"ledna", "února", "března", "dubna", "května", "června", "července", "srpna", "září", "října", "listopadu", "prosince"},
+		monthsShort:	[12]string{"led", "úno", "bře", "dub", "kvě", "čvn", "čvc", "srp", "zář", "říj", "lis", "pro"},
+		days:			[7]string{"neděle", "pondělí", "úterý", "středa", "čtvrtek", "pátek", "sobota"},
+		daysShort:		[7]string{"ne", "po", "út", "st", "čt", "pá", "so"},
+		am:				"dop.",
+		pm:				"odp.",
+		ordinal:		dateOrdinalSuffix("."),
+	},
+	"da": {
+		months:			[12]string{"januar", "februar", "marts", "april", "maj", "juni", "juli", "august", "september", "oktober", "november", "december"},
+		monthsShort:	[12]string{"jan.", "feb.", "mar.", "apr.", "maj", "jun.", "jul.", "aug.", "sep.", "okt.", "nov.", "dec."},
+		days:			[7]string{"søndag", "mandag", "tirsdag", "onsdag", "torsdag", "fredag", "lørdag"},
+		daysShort:		[7]string{"søn.", "man.", "tirs.", "ons.", "tors.", "fre.", "lør."},
+		am:				"AM",
+		pm:				"PM",
+		ordinal:		dateOrdinalSuffix("."),
+	},
+	"de": {
+		months:			[12]string{"Januar", "Februar", "März", "April", "Mai", "Juni", "Juli", "August", "September", "Oktober", "November", "Dezember"},
+		monthsShort:	[12]string{"Jan.", "Feb.", "März", "Apr.", "Mai", "Juni", "Juli", "Aug.", "Sept.", "Okt.", "Nov.", "Dez."},
+		days:			[7]string{"Sonntag", "Montag", "Dienstag", "Mittwoch", "Donnerstag", "Freitag", "Samstag"},
+		daysShort:		[7]string{"So.", "Mo.", "Di.", "Mi.", "Do.", "Fr.", "Sa."},
+		am:				"AM",
+		pm:				"PM",
+		ordinal:		dateOrdinalSuffix("."),
+	},
+	"es": {
+		months:			[12]string{"enero", "febrero", "marzo", "abril", "mayo", "junio", "julio", "agosto", "septiembre", "octubre", "noviembre", "diciembre"},
+		monthsShort:	[12]string{"ene", "feb", "mar", "abr", "may", "jun", "jul", "ago", "sept", "oct", "nov", "dic"},
+		days:			[7]string{"domingo", "lunes", "martes", "miércoles", "jueves", "viernes", "sábado"},
+		daysShort:		[7]string{"dom", "lun", "mar", "mié", "jue", "vie", "sáb"},
+		am:				"a. m.",
+		pm:				"p. m.",
+		ordinal:		dateOrdinalSuffix("º"),
+	},
+	"fi": {
+		months:			[12]string{"tammikuu", "helmikuu", "maaliskuu", "huhtikuu", "toukokuu", "kesäkuu", "heinäkuu", "elokuu", "syyskuu", "lokakuu", "marraskuu", "joulukuu"},
+		monthsGenitive:	[12]string{"tammikuuta", "helmikuuta", "maaliskuuta", "huhtikuuta", "toukokuuta", "kesäkuuta", "heinäkuuta", "elokuuta", "syyskuuta", "lokakuuta", "marraskuuta", "joulukuuta"},
+		monthsShort:	[12]string{"tammik.", "helmik.", "maalisk.", "huhtik.", "toukok.", "kesäk.", "heinäk.", "elok.", "syysk.", "lokak.", "marrask.", "jouluk."},
+		days:			[7]string{"sunnuntai", "maanantai", "tiistai", "keskiviikko", "torstai", "perjantai", "lauantai"},
+		daysShort:		[7]string{"su", "ma", "ti", "ke", "to", "pe", "la"},
+		am:				"ap.",
+		pm:				"ip.",
+		ordinal:		dateOrdinalSuffix("."),
+	},
+	"fr": {
+		months:			[12]string{"janvier", "février", "mars", "avril", "mai", "juin", "juillet", "août", "septembre", "octobre", "novembre", "décembre"},
+		monthsShort:	[12]string{"janv.", "févr.", "mars", "avr.", "mai", "juin", "juil.", "août", "sept.", "oct.", "nov.", "déc."},
+		days:			[7]string{"dimanche", "lundi", "mardi", "mercredi", "jeudi", "vendredi", "samedi"},
+		daysShort:		[7]string{"dim.", "lun.", "mar.", "mer.", "jeu.", "ven.", "sam."},
+		am:				"AM",
+		pm:				"PM",
+		ordinal:		func(day int) string {
+			if day == 1 {
+				return "er"
+			}
+			return ""
+		},
+	},
+	"it": {
+		months:			[12]string{"gennaio", "febbraio", "marzo", "aprile", "maggio", "giugno", "luglio", "agosto", "settembre", "ottobre", "novembre", "dicembre"},
+		monthsShort:	[12]string{"gen", "feb", "mar", "apr", "mag", "giu", "lug", "ago", "set", "ott", "nov", "dic"},
+		days:			[7]string{"domenica", "lunedì", "martedì", "mercoledì", "giovedì", "venerdì", "sabato"},
+		daysShort:		[7]string{"dom", "lun", "mar", "mer", "gio", "ven", "sab"},
+		am:				"AM",
+		pm:				"PM",
+		ordinal:		dateOrdinalSuffix("º"),
+	},
+	"nb": {
+		months:			[12]string{"januar", "februar", "mars", "april", "mai", "juni", "juli", "august", "september", "oktober", "november", "desember"},
+		monthsShort:	[12]string{"jan.", "feb.", "mar.", "apr.", "mai", "jun.", "jul.", "aug.", "sep.", "okt.", "nov.", "des."},
+		days:			[7]string{"søndag", "mandag", "tirsdag", "onsdag", "torsdag", "fredag", "lørdag"},
+		daysShort:		[7]string{"søn.", "man.", "tir.", "ons.", "tor.", "fre.", "lør."},
+		am:				"a.m.",
+		pm:				"p.m.",
+		ordinal:		dateOrdinalSuffix("."),
+	},
+	"nl": {
+		months:			[12]string{"januari", "februari", "maart", "april", "mei", "juni", "juli", "augustus", "september", "oktober", "november", "december"},
+		monthsShort:	[12]string{"jan", "feb", "mrt", "apr", "mei", "jun", "jul", "aug", "sep", "okt", "nov", "dec"},
+		days:			[7]string{"zondag", "maandag", "dinsdag", "woensdag", "donderdag", "vrijdag", "zaterdag"},
+		daysShort:		[7]string{"zo", "ma", "di", "wo", "do", "vr", "za"},
+		am:				"a.m.",
+		pm:				"p.m.",
+		ordinal:		dateOrdinalSuffix("e"),
+	},
+	"pl": {
+		months:			[12]string{"styczeń", "luty", "marzec", "kwiecień", "maj", "czerwiec", "lipiec", "sierpień", "wrzesień", "październik", "listopad", "grudzień"},
+		monthsGenitive:	[12]string{"stycznia", "lutego", "marca", "kwietnia", "maja", "czerwca", "lipca", "sierpnia", "września", "października", "listopada", "grudnia"},
+		monthsShort:	[12]string{"sty", "lut", "mar", "kwi", "maj", "cze", "lip", "sie", "wrz", "paź", "lis", "gru"},
+		days:			[7]string{"niedziela", "poniedziałek", "wtorek", "środa", "czwartek", "piątek", "sobota"},
+		daysShort:		[7]string{"niedz.", "pon.", "wt.", "śr.", "czw.", "pt.", "sob."},
+		am:				"AM",
+		pm:				"PM",
+		ordinal:		dateOrdinalSuffix("."),
+	},
+	"pt": {
+		months:			[12]string{"janeiro", "fevereiro", "março", "abril", "maio", "junho", "julho", "agosto", "setembro", "outubro", "novembro", "dezembro"},
+		monthsShort:	[12]string{"jan.", "fev.", "mar.", "abr.", "mai.", "jun.", "jul.", "ago.", "set.", "out.", "nov.", "dez."},
+		days:			[7]string{"domingo", "segunda-feira", "terça-feira", "quarta-feira", "quinta-feira", "sexta-feira", "sábado"},
+		daysShort:		[7]string{"dom.", "seg.", "ter.", "qua.", "qui.", "sex.", "sáb."},
+		am:				"AM",
+		pm:				"PM",
+		ordinal:		dateOrdinalSuffix("º"),
+	},
+	"ru": {
+		months:			[12]string{"январь", "февраль", "март", "апрель", "май", "июнь", "июль", "август", "сентябрь", "октябрь", "ноябрь", "декабрь"},
+		monthsGenitive:	[12]string{"января", "февраля", "марта", "апреля", "мая", "июня", "июля", "августа", "сентября", "октября", "ноября", "декабря"},
+		monthsShort:	[12]string{"янв.", "февр.", "мар.", "апр.", "мая", "июн.", "июл.", "авг.", "сент.", "окт.", "нояб.", "дек."},
+		days:			[7]string{"воскресенье", "понедельник", "вторник", "среда", "четверг", "пятница", "суббота"},
+		daysShort:		[7]string{"вс", "пн", "вт", "ср", "чт", "пт", "сб"},
+		am:				"AM",
+		pm:				"PM",
+		ordinal:		dateOrdinalSuffix("-е"),
+	},
+	"sv": {
+		months:			[12]string{"januari", "februari", "mars", "april", "maj", "juni", "juli", "augusti", "september", "oktober", "november", "december"},
+		monthsShort:	[12]string{"jan.", "feb.", "mars", "apr.", "maj", "juni", "juli", "aug.", "sep.", "okt.", "nov.", "dec."},
+		days:			[7]string{"söndag", "måndag", "tisdag", "onsdag", "torsdag", "fredag", "lördag"},
+		daysShort:		[7]string{"sön", "mån", "tis", "ons", "tors", "fre", "lör"},
+		am:				"fm",
+		pm:				"em",
+		ordinal:		func(day int) string {
+			if (day % 10 == 1 || day % 10 == 2) && day % 100 != 11 && day % 100 != 12 {
+				return ":a"
+			}
+			return ":e"
+		},
+	},
+}
+
+// Languages which share the date names of another
+var dateLocaleAliases = map[string]string{
+	"no": "nb",
+	"nn": "nb",
+}
+
+// Finds the date names of a locale: its own, its language's or, failing those, English
+func dateLocale(locale string) dateNames {
+	locale = normaliseLocale(locale)
+	if names, ok := dateLocales[locale]; ok {
+		return names
+	}
+
+	language, _, _ := strings.Cut(locale, "-")
+	if alias, ok := dateLocaleAliases[language]; ok {
+		language = alias
+	}
+	if names, ok := dateLocales[language]; ok {
+		return names
+	}
+
+	return dateLocales["en"]
+}
+
+// Finds the name token of a Go layout (following the rules of `time.Format()`) at position `i`, if there is one
+func dateNameToken(layout string, i int) string {
+	startsWithLower := func(j int) bool {
+		return j < len(layout) && 'a' <= layout[j] && layout[j] <= 'z'
+	}
+
+	switch {
+		case strings.HasPrefix(layout[i:], "January"):
+			return "January"
+		case strings.HasPrefix(layout[i:], "Jan") && !startsWithLower(i + 3):
+			return "Jan"
+		case strings.HasPrefix(layout[i:], "Monday"):
+			return "Monday"
+		case strings.HasPrefix(layout[i:], "Mon") && !startsWithLower(i + 3):
+			return "Mon"
+		case strings.HasPrefix(layout[i:], "PM"), strings.HasPrefix(layout[i:], "pm"):
+			return layout[i:i + 2]
+	}
+
+	return ""
+}
+
+// Formats a time using a Go layout with the month and day names and AM / PM markers of a locale. Months use their
+// genitive form (where a language has one) when the day of the month is also shown
+func formatTimeLocale(t time.Time, layout string, locale string) string {
+	if len(locale) == 0 || normaliseLocale(locale) == "en" {
+		return t.Format(layout)
+	}
+
+	names	:= dateLocale(locale)
+	months	:= names.months
+	if len(names.monthsGenitive[0]) > 0 && strings.Contains(strings.ReplaceAll(layout, "2006", ""), "2") {
+		months = names.monthsGenitive
+	}
+
+	output	:= strings.Builder{}
+	start	:= 0
+	for i := 0; i < len(layout); {
+		token := dateNameToken(layout, i)
+		if len(token) == 0 {
+			i++
+			continue
+		}
+
+		output.WriteString(t.Format(layout[start:i]))
+		switch token {
+			case "January":	output.WriteString(months[t.Month() - 1])
+			case "Jan":		output.WriteString(names.monthsShort[t.Month() - 1])
+			case "Monday":	output.WriteString(names.days[t.Weekday()])
+			case "Mon":		output.WriteString(names.daysShort[t.Weekday()])
+			case "PM", "pm":
+				marker := names.am
+				if t.Hour() >= 12 {
+					marker = names.pm
+				}
+				if token == "pm" {
+					marker = strings.ToLower(marker)
+				}
+				output.WriteString(marker)
+		}
+
+		i += len(token)
+		start = i
+	}
+	output.WriteString(t.Format(layout[start:]))
+
+	return output.String()
+}
+
+// Removes a leading `localeArgument` from the parameters of a date function, returning it ("" if there was none)
+func splitLocaleArgument(params []any) (string, []any) {
+	if len(params) > 0 {
+		if locale, ok := params[0].(localeArgument); ok {
+			return string(locale), params[1:]
+		}
+	}
+
+	return "", params
+}
+
+// Creates versions of the date functions (under whatever names they are registered with) which format dates in a
+// locale ("" for the default locale) unless they are given a locale of their own
+func (tm *TemplateManager) dateFunctions(locale string) map[string]any {
+	localised := map[uintptr]bool{}
+	for _, function := range []any{date, datetime, formattime, humanize, naturaltime, timeFn} {
+		localised[reflect.ValueOf(function).Pointer()] = true
+	}
+
+	functions := map[string]any{}
+	for name, function := range tm.functions {
+		value := reflect.ValueOf(function)
+		if value.Kind() != reflect.Func || !localised[value.Pointer()] {
+			continue
+		}
+
+		call := function.(func(...any) (string, error))
+		functions[name] = func(params ...any) (string, error) {
+			if given, _ := splitLocaleArgument(params); len(given) == 0 {
+				current := locale
+				if len(current) == 0 {
+					current = tm.defaultLocale
+				}
+				params = append([]any{localeArgument(current)}, params...)
+			}
+			return call(params...)
+		}
+	}
+
+	return functions
+}
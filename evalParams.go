@@ -0,0 +1,180 @@
+package templateManager
+
+/*
+Functions dedicated to vars whose values are template pipelines, evaluated against the merged params at render time
+*/
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/template/parse"
+)
+
+// A var declared with the `eval` modifier. Its pipeline is compiled when the file is parsed and evaluated once per render
+type evalVar struct {
+	pipeline		string
+	template		*Template
+	dependencies	[]string
+}
+
+// Holds the result of a single evaluation (so that the value keeps its type rather than being printed)
+type evalResult struct {
+	value any
+}
+
+// The params key used to hand the `evalResult` to the pipeline's template
+const evalResultKey = "TemplateManagerEvalResult"
+
+// Shows the pipeline in debugging output
+func (v evalVar) String() string {
+	return "eval(" + v.pipeline + ")"
+}
+
+// Compiles the pipeline of an `eval` var into a template which stores its result
+func (tm *TemplateManager) newEvalVar(name string, pipeline string) (evalVar, error) {
+	tmpl := tm.configureNewTemplate(NewTemplate(tm.templateType, "eval " + name))
+	tmpl.Funcs(map[string]any{
+		"evalStore": func(result *evalResult, value any) string {
+			result.value = value
+			return ""
+		},
+	})
+
+	_, err := tmpl.Parse(tm.delimiterLeft + ` evalStore (index . "` + evalResultKey + `") (` + pipeline + `) ` + tm.delimiterRight)
+	if err != nil {
+		return evalVar{}, err
+	}
+
+	dependencies, err := pipelineDependencies(pipeline, tm.delimiterLeft, tm.delimiterRight)
+	if err != nil {
+		return evalVar{}, err
+	}
+
+	return evalVar{pipeline: pipeline, template: tmpl, dependencies: dependencies}, nil
+}
+
+// Executes the pipeline against `params`, returning its result
+func (v evalVar) evaluate(params Params) (any, error) {
+	result	:= &evalResult{}
+	data	:= make(Params, len(params) + 1)
+	for key, value := range params {
+		data[key] = value
+	}
+	data[evalResultKey] = result
+
+	err := v.template.Execute(io.Discard, data)
+	if err != nil {
+		return nil, err
+	}
+
+	return result.value, nil
+}
+
+// Replaces every `eval` var in `params` with its evaluated value. Eval vars that use other eval vars are evaluated
+// after them, and eval vars that depend upon each other are an error
+func evaluateParams(params Params) error {
+	pending := map[string]evalVar{}
+	for key, value := range params {
+		if variable, ok := value.(evalVar); ok {
+			pending[key] = variable
+		}
+	}
+
+	if len(pending) == 0 {
+		return nil
+	}
+
+	keys := []string{}
+	for key := range pending {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	evaluated	:= map[string]bool{}
+	var evaluate func(key string, chain []string) error
+	evaluate = func(key string, chain []string) error {
+		if evaluated[key] {
+			return nil
+		}
+
+		for _, link := range chain {
+			if link == key {
+				return fmt.Errorf("eval vars depend upon each other: %s", strings.Join(append(chain, key), " -> "))
+			}
+		}
+
+		for _, dependency := range pending[key].dependencies {
+			if _, ok := pending[dependency]; ok && dependency != key {
+				err := evaluate(dependency, append(chain, key))
+				if err != nil {
+					return err
+				}
+			}
+		}
+
+		value, err := pending[key].evaluate(params)
+		if err != nil {
+			return fmt.Errorf("eval var %q: %s", key, err.Error())
+		}
+
+		params[key]		= value
+		evaluated[key]	= true
+
+		return nil
+	}
+
+	for _, key := range keys {
+		err := evaluate(key, []string{})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Finds the top level params used by a pipeline (e.g. `.BaseUrl | suffix .Page.Path` uses "BaseUrl" and "Page")
+func pipelineDependencies(pipeline string, left string, right string) ([]string, error) {
+	tree := parse.New("eval")
+	tree.Mode = parse.SkipFuncCheck
+
+	_, err := tree.Parse(left + " " + pipeline + " " + right, left, right, map[string]*parse.Tree{})
+	if err != nil {
+		return nil, err
+	}
+
+	dependencies := []string{}
+
+	var walk func(node parse.Node)
+	walk = func(node parse.Node) {
+		switch typed := node.(type) {
+			case *parse.ListNode:
+				for _, child := range typed.Nodes {
+					walk(child)
+				}
+			case *parse.ActionNode:
+				walk(typed.Pipe)
+			case *parse.PipeNode:
+				for _, command := range typed.Cmds {
+					walk(command)
+				}
+			case *parse.CommandNode:
+				for _, argument := range typed.Args {
+					walk(argument)
+				}
+			case *parse.ChainNode:
+				walk(typed.Node)
+			case *parse.FieldNode:
+				dependencies = append(dependencies, typed.Ident[0])
+			case *parse.VariableNode:
+				if len(typed.Ident) > 1 && typed.Ident[0] == "$" {
+					dependencies = append(dependencies, typed.Ident[1])
+				}
+		}
+	}
+	walk(tree.Root)
+
+	return dependencies, nil
+}
@@ -0,0 +1,110 @@
+package templateManager
+
+/*
+Functions dedicated to finding the translatable messages used by templates (for keeping catalogs up to date)
+*/
+
+import (
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/paul-norman/go-template-manager/fsWalk"
+)
+
+// A translatable message used by the templates, as returned by `ExtractMessages()`
+type ExtractedMessage struct {
+	Key			string
+	Plural		bool
+	References	[]string
+}
+
+// Scans every template file (including layouts, partials and components) for `t` / `tn` calls with literal keys
+// and `trans` blocks, returning each message key (sorted) with the files and lines that use it
+func (tm *TemplateManager) ExtractMessages() ([]ExtractedMessage, error) {
+	left	:= regexp.QuoteMeta(tm.delimiterLeft)
+	right	:= regexp.QuoteMeta(tm.delimiterRight)
+
+	findActions, err := regexp.Compile("(?s)" + left + "(.*?)" + right)
+	if err != nil {
+		return nil, err
+	}
+	findCalls := regexp.MustCompile("(?:^|[\\s(|])(tn?)\\s+(\"(?:[^\"\\\\]|\\\\.)*\"|`[^`]*`)")
+
+	messages := map[string]*ExtractedMessage{}
+	add := func(key string, plural bool, name string, content string, offset int) {
+		message, ok := messages[key]
+		if !ok {
+			message = &ExtractedMessage{Key: key, References: []string{}}
+			messages[key] = message
+		}
+
+		message.Plural = message.Plural || plural
+		message.References = append(message.References, fmt.Sprintf("%s:%d", name, strings.Count(content[:offset], "\n") + 1))
+	}
+
+	walk := func(path string, info fs.DirEntry, err error) error {
+		if err != nil || info == nil {
+			return err
+		}
+
+		if info.IsDir() {
+			return nil
+		}
+
+		if _, err := hasExtension(path, tm.extensions); err != nil {
+			return nil
+		}
+
+		buffer, err := fsWalk.ReadFile(path, tm.fileSystem)
+		if err != nil {
+			return err
+		}
+		content := string(buffer)
+		name, _ := cleanPath(path, tm.directory)
+
+		blocks, err := tm.findTransBlocks(content)
+		if err != nil {
+			return fmt.Errorf("%s: %s", name, err.Error())
+		}
+
+		for _, block := range blocks {
+			add(transKey(content[block[1]:block[2]]), false, name, content, block[0])
+		}
+
+		for _, action := range findActions.FindAllStringSubmatchIndex(content, -1) {
+			code := content[action[2]:action[3]]
+			for _, call := range findCalls.FindAllStringSubmatchIndex(code, -1) {
+				key, err := strconv.Unquote(code[call[4]:call[5]])
+				if err != nil {
+					return fmt.Errorf("%s: invalid message key %s", name, code[call[4]:call[5]])
+				}
+				add(key, code[call[2]:call[3]] == "tn", name, content, action[0])
+			}
+		}
+
+		return nil
+	}
+
+	if tm.fileSystem != nil {
+		err = fsWalk.WalkDir(tm.fileSystem, tm.directory, walk)
+	} else {
+		err = filepath.WalkDir(tm.directory, walk)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	extracted := []ExtractedMessage{}
+	for _, message := range messages {
+		extracted = append(extracted, *message)
+	}
+	sort.Slice(extracted, func(i, j int) bool { return extracted[i].Key < extracted[j].Key })
+
+	return extracted, nil
+}
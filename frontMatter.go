@@ -0,0 +1,115 @@
+package templateManager
+
+/*
+Functions dedicated to the parsing of front matter blocks (YAML, TOML or JSON) found at the start of template files
+*/
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// Finds a front matter block at the very start of a file, returning the block (as a whole), its format and its body.
+// YAML (or JSON) front matter is delimited by `---` lines and TOML front matter by `+++` lines.
+func findFrontMatter(content string) (string, string, string) {
+	content = strings.TrimPrefix(content, "\uFEFF")
+
+	if match := regexps["findFrontMatterYaml"].FindStringSubmatch(content); match != nil {
+		if strings.HasPrefix(strings.TrimSpace(match[1]), "{") {
+			return match[0], "json", match[1]
+		}
+		return match[0], "yaml", match[1]
+	}
+
+	if match := regexps["findFrontMatterToml"].FindStringSubmatch(content); match != nil {
+		return match[0], "toml", match[1]
+	}
+
+	return "", "", ""
+}
+
+// Removes any front matter block from the start of a file's content
+func stripFrontMatter(content []byte) []byte {
+	block, _, _ := findFrontMatter(string(content))
+	if len(block) == 0 {
+		return content
+	}
+
+	return []byte(strings.TrimPrefix(strings.TrimPrefix(string(content), "\uFEFF"), block))
+}
+
+// Parses a front matter (or params file) body of the given format into typed values.
+// Slices and maps are typed in the same way as variables declared with `{{ var }}` (e.g. a list of tags is a `[]string`)
+func parseParamsDocument(format string, body string) (Params, error) {
+	raw := map[string]any{}
+
+	err := decodeDocument(format, []byte(body), &raw)
+	if err != nil {
+		return nil, err
+	}
+
+	params := Params{}
+	for key, value := range raw {
+		params[key] = normaliseDecodedValue(value)
+	}
+
+	return params, nil
+}
+
+// Decodes a YAML, TOML or JSON document into `into` (JSON numbers are kept as `json.Number` to preserve ints)
+func decodeDocument(format string, document []byte, into any) error {
+	switch format {
+		case "yaml":
+			return yaml.Unmarshal(document, into)
+		case "toml":
+			_, err := toml.Decode(string(document), into)
+			return err
+		case "json":
+			decoder := json.NewDecoder(bytes.NewReader(document))
+			decoder.UseNumber()
+			return decoder.Decode(into)
+	}
+
+	return fmt.Errorf("unknown format %q", format)
+}
+
+// Converts decoded values into the types used by template variables (ints as `int`, typed slices and maps etc)
+func normaliseDecodedValue(value any) any {
+	switch typed := value.(type) {
+		case int64:
+			return int(typed)
+		case json.Number:
+			if val, err := typed.Int64(); err == nil {
+				return int(val)
+			}
+			val, _ := typed.Float64()
+			return val
+		case []any:
+			values := make([]any, len(typed))
+			for i, v := range typed {
+				values[i] = normaliseDecodedValue(v)
+			}
+			return typedLiteralSlice(values)
+		case []map[string]any:
+			values := make([]any, len(typed))
+			for i, v := range typed {
+				values[i] = normaliseDecodedValue(v)
+			}
+			return typedLiteralSlice(values)
+		case map[string]any:
+			keys	:= make([]any, 0, len(typed))
+			values	:= make([]any, 0, len(typed))
+			for k, v := range typed {
+				keys	= append(keys, k)
+				values	= append(values, normaliseDecodedValue(v))
+			}
+			return typedLiteralMap(keys, values)
+	}
+
+	return value
+}
@@ -8,6 +8,7 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	HT "html/template"
 	"math/rand"
 	"net/url"
 	"reflect"
@@ -28,12 +29,18 @@ func getDefaultFunctions() map[string]any {
 	return map[string]any{
 		"add":				add,
 		"bool":				toBool,
+		"calendar":			calendar,
 		"capfirst":			capfirst,
 		"collection":		collection, 
+		"compact":			compact,
 		"concat":			concat,
 		"contains":			contains,
+		"currency":			currency,
 		"cut":				cut,
 		"date":				date,
+		"dateadd":			dateadd,
+		"datediff":			datediff,
+		"datesub":			datesub,
 		"datetime":			datetime,
 		"default":			defaultVal,
 		"divide":			divide,
@@ -41,8 +48,10 @@ func getDefaultFunctions() map[string]any {
 		"dividefloor":		divideFloor,
 		"divisibleby":		divisibleBy,
 		"dl":				dl,
+		"endof":			endof,
 		"endswith":			endswith,
 		"equal":			equal,
+		"filesize":			filesize,
 		"first":			first,
 		"firstof":			firstOf,
 		"float":			toFloat,
@@ -51,7 +60,11 @@ func getDefaultFunctions() map[string]any {
 		"gte":				greaterThanEqual,
 		"htmldecode":		htmlDecode,
 		"htmlencode":		htmlEncode,
+		"humanize":			humanize,
 		"int":				toInt,
+		"isoweek":			isoweek,
+		"isweekday":		isweekday,
+		"isweekend":		isweekend,
 		"iterable":			iterable,
 		"join":				join,
 		"jsondecode":		jsonDecode,
@@ -64,19 +77,25 @@ func getDefaultFunctions() map[string]any {
 		"list":				list,
 		"lto":				lessThan,
 		"lte":				lessThanEqual,
+		"locale":			localeFn,
 		"localtime":		localtime,
 		"lower":			lower,
 		"lpad":				lpad,
 		"ltrim":			ltrim,
+		"markdown":			markdown,
 		"md5":				md5Fn,
 		"mktime":			mktime,
 		"multiply":			multiply,
+		"naturaltime":		naturaltime,
 		"nl2br":			nl2br,
 		"notequal":			notequal,
 		"now":				now, 
+		"number":			number,
 		"ol":				ol,
 		"ordinal":			ordinal,
+		"parsedate":		parsedate,
 		"paragraph":		paragraph,
+		"percent":			percent,
 		"pluralise":		pluralise,
 		"prefix":			prefix,
 		"query":			query, 
@@ -91,16 +110,19 @@ func getDefaultFunctions() map[string]any {
 		"sha256":			sha256Fn,
 		"sha512":			sha512Fn,
 		"split":			split,
+		"startof":			startof,
 		"startswith":		startswith,
 		"string":			toString,
 		"striptags":		stripTags,
 		"substr":			substr,
 		"subtract": 		subtract,
 		"suffix":			suffix,
+		"t":				translateFn,
 		"time":				timeFn,
 		"timesince":		timeSince,
 		"timeuntil":		timeUntil,
 		"title":			title,
+		"tn":				translatePluralFn,
 		"trim":				trim,
 		"truncate":			truncate,
 		"truncatewords":	truncatewords,
@@ -226,6 +248,62 @@ func add(value reflect.Value, to reflect.Value) (reflect.Value, error) {
 	return to, nil
 }
 
+/*
+ func calendar(year int, month int, firstWeekday int|string, items []any, field string) (Calendar, error)
+Lays out a month as a grid of whole weeks starting on `firstWeekday` (0 / "sunday" to 6 / "saturday"), flagging today,
+weekends and days outside the month. Optional `items` (dates, or maps / structs with a date in `field`, default "Date")
+are placed on the days that they fall on.
+*/
+func calendar(year any, month any, firstWeekday any, items ...any) (Calendar, error) {
+	sig := "calendar(year int, month int, firstWeekday int|string, items []any, field string)"
+
+	y, err := interfaceHelperConvertToInt(year)
+	if err != nil {
+		err := logError(sig + " year must be a number")
+		return Calendar{}, err
+	}
+
+	m, ok := month.(time.Month)
+	if !ok {
+		tmp, err := interfaceHelperConvertToInt(month)
+		if err != nil {
+			err := logError(sig + " month must be a number")
+			return Calendar{}, err
+		}
+		m = time.Month(tmp)
+	}
+
+	weekday, err := calendarWeekday(firstWeekday)
+	if err != nil {
+		err := logError(sig + " " + err.Error())
+		return Calendar{}, err
+	}
+
+	if len(items) > 2 {
+		err := logError(sig + " expects at most 5 arguments, received %d", len(items) + 3)
+		return Calendar{}, err
+	}
+
+	list, field := reflect.Value{}, "Date"
+	if len(items) > 0 {
+		list = reflect.ValueOf(items[0])
+	}
+	if len(items) > 1 {
+		if field, ok = items[1].(string); !ok {
+			err := logError(sig + " field must be a string")
+			return Calendar{}, err
+		}
+	}
+
+	result, err := buildCalendar(y, m, weekday, list, field, time.Now())
+	if err != nil {
+		err := logError(sig + " " + err.Error())
+		return Calendar{}, err
+	}
+
+	return result, nil
+}
+
 /*
  func capfirst[T any](value T) (T, error)
 Capitalises the first letter of strings. Does not alter any other letters.
@@ -285,6 +363,15 @@ func collection(pairs ...any) (map[string]any, error) {
 	return collection, nil
 }
 
+/*
+ func compact[T any](decimals int, value T) (string, error)
+Abbreviates a large number in the style of the locale (e.g. "1.2K"). `decimals` is the maximum number of decimal
+places shown (default: 1).
+*/
+func compact(args ...reflect.Value) (string, error) {
+	return numberCompact("en", args...)
+}
+
 /*
  func concat(values ...any) (string, error)
 Concatenates any number of string-able values together in the order that they were declared.
@@ -383,6 +470,15 @@ func contains(find reflect.Value, within reflect.Value) (bool, error) {
 	return false, err
 }
 
+/*
+ func currency[T any](code string, decimals int, value T) (string, error)
+Formats an amount of a currency (an ISO 4217 code) in the style of the locale. Without `decimals`, the currency's
+usual number of decimal places is shown.
+*/
+func currency(code reflect.Value, args ...reflect.Value) (string, error) {
+	return numberCurrency("en", code, args...)
+}
+
 /*
  func cut[T any](remove string, from T) (T, error)
 Will `remove` a string value that is contained in the `from` value.
@@ -408,19 +504,65 @@ It can accept various parameter combinations:
 */
 func date(params ...any) (string, error) {
 	format := dateDefaultDateFormat
+	locale, params := splitLocaleArgument(params)
 
 	if len(params) == 0 {
-		return timeFn(format)
+		return timeFn(localeArgument(locale), format)
 	} else if len(params) == 1 {
 		switch val := params[0].(type) {
 			case time.Time:
-				return timeFn(format, val)
+				return timeFn(localeArgument(locale), format, val)
 			case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
-				return timeFn(format, params[0])
+				return timeFn(localeArgument(locale), format, params[0])
 		}
 	}
 	
-	return timeFn(params...)
+	return timeFn(append([]any{localeArgument(locale)}, params...)...)
+}
+
+/*
+ func dateadd(amount time.Duration|string|int, unit string, t time.Time|string) (time.Time, error)
+Adds an amount of time to `t`. The amount may be a time.Duration or duration string ("1h30m"), a relative phrase
+("+1 month 2 days") or a number followed by a unit (`dateadd 3 "months" .Date`).
+*/
+func dateadd(params ...any) (time.Time, error) {
+	return dateArithmeticHelper("dateadd(amount time.Duration|string|int, unit string, t time.Time|string)", 1, params)
+}
+
+/*
+ func datediff(unit string, from time.Time|string, to time.Time|string) (int, error)
+Counts the whole units (second, minute, hour, day, week, month, quarter, year) from `from` until `to`.
+*/
+func datediff(unit string, from any, to any) (int, error) {
+	sig := "datediff(unit string, from time.Time|string, to time.Time|string)"
+
+	start, err := dateValue(from)
+	if err != nil {
+		err := logError(sig + " " + err.Error())
+		return 0, err
+	}
+
+	end, err := dateValue(to)
+	if err != nil {
+		err := logError(sig + " " + err.Error())
+		return 0, err
+	}
+
+	difference, err := dateDifference(start, end, unit)
+	if err != nil {
+		err := logError(sig + " " + err.Error())
+		return 0, err
+	}
+
+	return difference, nil
+}
+
+/*
+ func datesub(amount time.Duration|string|int, unit string, t time.Time|string) (time.Time, error)
+Subtracts an amount of time from `t` (see `dateadd`).
+*/
+func datesub(params ...any) (time.Time, error) {
+	return dateArithmeticHelper("datesub(amount time.Duration|string|int, unit string, t time.Time|string)", -1, params)
 }
 
 /*
@@ -439,19 +581,20 @@ It can accept various parameter combinations:
 */
 func datetime(params ...any) (string, error) {
 	format := dateDefaultDatetimeFormat
+	locale, params := splitLocaleArgument(params)
 
 	if len(params) == 0 {
-		return timeFn(format)
+		return timeFn(localeArgument(locale), format)
 	} else if len(params) == 1 {
 		switch val := params[0].(type) {
 			case time.Time:
-				return timeFn(format, val)
+				return timeFn(localeArgument(locale), format, val)
 			case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
-				return timeFn(format, params[0])
+				return timeFn(localeArgument(locale), format, params[0])
 		}
 	}
 	
-	return timeFn(params...)
+	return timeFn(append([]any{localeArgument(locale)}, params...)...)
 }
 
 /*
@@ -568,6 +711,28 @@ func dl(value reflect.Value) (string, error) {
 	return listHelper(value, "dl")
 }
 
+/*
+ func endof(unit string, t time.Time|string) (time.Time, error)
+Finds the last moment of the minute, hour, day, week (ending on Sunday), month, quarter or year containing `t`.
+*/
+func endof(unit string, t any) (time.Time, error) {
+	sig := "endof(unit string, t time.Time|string)"
+
+	value, err := dateValue(t)
+	if err != nil {
+		err := logError(sig + " " + err.Error())
+		return value, err
+	}
+
+	end, err := endOfDate(value, unit)
+	if err != nil {
+		err := logError(sig + " " + err.Error())
+		return value, err
+	}
+
+	return end, nil
+}
+
 /*
  func endswith(find any, value any) (bool, error)
 Determines if a string ends with a certain value.
@@ -657,6 +822,15 @@ func equal(values ...reflect.Value) (bool, error) {
 	return true, nil
 }
 
+/*
+ func filesize[T any](decimals int, value T) (string, error)
+Formats a number of bytes using decimal (1000 based) units in the style of the locale (e.g. "3.4 MB"). `decimals` is
+the maximum number of decimal places shown (default: 1).
+*/
+func filesize(args ...reflect.Value) (string, error) {
+	return numberFilesize("en", args...)
+}
+
 /*
  func first(value string|slice|array) (any, error)
 Gets the first value from slices / arrays / maps / structs or the first word from strings.
@@ -756,9 +930,30 @@ func firstOf(values ...reflect.Value) (reflect.Value, error) {
  func formattime(format string, t time.Time) (string, error)
 
 Formats a time.Time object for display.
+May be preceded by a locale (from the `locale` function) to use its month and day names.
 */
-func formattime(format string, t time.Time) (string, error) {
-	return t.Format(dateFormatHelper(format)), nil
+func formattime(params ...any) (string, error) {
+	sig := "formattime(format string, t time.Time)"
+
+	locale, params := splitLocaleArgument(params)
+	if len(params) != 2 {
+		err := logError(sig + " expects 2 arguments, received %d", len(params))
+		return "", err
+	}
+
+	format, ok := params[0].(string)
+	if !ok {
+		err := logError(sig + " format must be a string")
+		return "", err
+	}
+
+	t, ok := params[1].(time.Time)
+	if !ok {
+		err := logError(sig + " t must be a time.Time")
+		return "", err
+	}
+
+	return formatDate(t, format, locale), nil
 }
 
 /*
@@ -903,6 +1098,127 @@ func htmlEncode(value reflect.Value) (reflect.Value, error) {
 	return recursiveHelper(value, reflect.ValueOf(htmlEncode))
 }
 
+/*
+ func humanize(granularity string, duration time.Duration|int|string) (string, error)
+Describes a duration in words (e.g. "1 hour, 5 minutes"), listing units down to `granularity` (`second` by default,
+or `minute`, `hour`, `day`, `week`, `year`). The duration may be a time.Duration, a number of seconds or a string such
+as "90m". May be preceded by a locale (from the `locale` function).
+*/
+func humanize(params ...any) (string, error) {
+	sig := "humanize(granularity string, duration time.Duration|int|string)"
+
+	locale, params := splitLocaleArgument(params)
+	granularity := "second"
+	if len(params) == 2 {
+		g, ok := params[0].(string)
+		if !ok {
+			err := logError(sig + " granularity must be a string")
+			return "", err
+		}
+		granularity, params = g, params[1:]
+	}
+	if len(params) != 1 {
+		err := logError(sig + " expects 1 or 2 arguments, received %d", len(params))
+		return "", err
+	}
+
+	var duration time.Duration
+	switch val := params[0].(type) {
+		case time.Duration:
+			duration = val
+		case string:
+			d, err := time.ParseDuration(val)
+			if err != nil {
+				err := logError(sig + " " + err.Error())
+				return "", err
+			}
+			duration = d
+		default:
+			seconds, err := interfaceHelperConvertToInt64(val)
+			if err != nil {
+				err := logError(sig + " can't convert type %T to a duration", val)
+				return "", err
+			}
+			duration = time.Duration(seconds) * time.Second
+	}
+
+	text, err := describeDuration(duration, granularity, locale)
+	if err != nil {
+		err := logError(sig + " " + err.Error())
+		return "", err
+	}
+
+	return text, nil
+}
+
+/*
+ func isoweek(t time.Time|string) (int, error)
+Returns the ISO 8601 week number of `t`.
+*/
+func isoweek(t any) (int, error) {
+	sig := "isoweek(t time.Time|string)"
+
+	value, err := dateValue(t)
+	if err != nil {
+		err := logError(sig + " " + err.Error())
+		return 0, err
+	}
+
+	_, week := value.ISOWeek()
+
+	return week, nil
+}
+
+/*
+ func isweekday(days ...string, t time.Time|string) (bool, error)
+Checks whether `t` falls on one of the named days ("monday", "sat"...), or on Monday to Friday if none are named.
+*/
+func isweekday(params ...any) (bool, error) {
+	sig := "isweekday(days ...string, t time.Time|string)"
+
+	if len(params) == 0 {
+		err := logError(sig + " expects at least 1 argument")
+		return false, err
+	}
+
+	value, err := dateValue(params[len(params) - 1])
+	if err != nil {
+		err := logError(sig + " " + err.Error())
+		return false, err
+	}
+
+	if len(params) == 1 {
+		return value.Weekday() != time.Saturday && value.Weekday() != time.Sunday, nil
+	}
+
+	for _, param := range params[:len(params) - 1] {
+		name, _ := param.(string)
+		day, ok := dateWeekdays[strings.ToLower(name)]
+		if !ok {
+			err := logError(sig + " unknown day %v", param)
+			return false, err
+		}
+		if day == value.Weekday() {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+/*
+ func isweekend(t time.Time|string) (bool, error)
+Checks whether `t` falls on a Saturday or Sunday.
+*/
+func isweekend(t any) (bool, error) {
+	weekday, err := isweekday(t)
+	if err != nil {
+		return false, err
+	}
+
+	return !weekday, nil
+}
+
 /*
  func iterable(value ...int) ([]int, error)
 Creates an integer slice so as to spoof a `for` loop:
@@ -1454,6 +1770,14 @@ func lpad(length reflect.Value, pad reflect.Value, value reflect.Value) (reflect
 	return recursiveHelper(value, reflect.ValueOf(lpad), length, pad)
 }
 
+/*
+ func locale(locale string) (localeArgument, error)
+Chooses the locale used by a date function (e.g. `date (locale "fr") "l j F Y" .Time`), overriding the render's locale
+*/
+func localeFn(locale string) (localeArgument, error) {
+	return localeArgument(locale), nil
+}
+
 /*
  func localtime(location string|time.Location, t time.Time) (time.Time, error)
 Localises a time.Time object to display local times / dates.
@@ -1526,6 +1850,28 @@ func ltrim(remove reflect.Value, value reflect.Value) (reflect.Value, error) {
 	return recursiveHelper(value, reflect.ValueOf(ltrim), remove)
 }
 
+/*
+ func markdown(value string) (template.HTML, error)
+Converts Markdown (CommonMark, with tables) to HTML. Raw HTML and dangerous links are removed from the output.
+*/
+func markdown(value any) (HT.HTML, error) {
+	sig := "markdown(value string)"
+
+	source, err := interfaceHelperConvertToString(value)
+	if err != nil {
+		err := logError(sig + " " + err.Error())
+		return "", err
+	}
+
+	rendered, err := renderMarkdown(markdownRenderer, source)
+	if err != nil {
+		err := logError(sig + " " + err.Error())
+		return "", err
+	}
+
+	return HT.HTML(rendered), nil
+}
+
 /*
  func md5(input any) (string, error)
 Computes an md5 hash of the input.
@@ -1562,7 +1908,7 @@ Returns the current time if an invalid input is given.
 Supports Go, Python and PHP formatting standards.
 It can accept various parameter combinations:
  mktime()                           // Current time
- mktime(time string)                // Parse from a `time.RFC3339` formatted string
+ mktime(time string)                // Parse from a date string in any format that `parsedate` understands
                                         // mktime "2019-04-23T11:30:05Z"
  mktime(layout string, time string) // Parse from a custom formatted string using the given layout
                                         // mktime "2006-01-02T15:04:05Z07:00" "2019-04-23T11:30:05Z"
@@ -1574,9 +1920,9 @@ func mktime(params ...string) (time.Time, error) {
 	t	:= time.Now()
 
 	if len(params) == 1 {
-		tmp, err := time.Parse(time.RFC3339, params[0])
+		tmp, err := parseDate(params[0], t.In(dateLocalTimezone))
 		if err != nil {
-			err := logError(sig + " Invalid RFC3339 (\"" + time.RFC3339 + "\") passed: mktime(\"" + params[0] + "\")")
+			err := logError(sig + " Invalid date passed: mktime(\"" + params[0] + "\")")
 			return t.In(dateLocalTimezone), err
 		}
 		t = tmp
@@ -1633,6 +1979,45 @@ func multiply(multiplier reflect.Value, value reflect.Value) (reflect.Value, err
 	return recursiveHelper(value, reflect.ValueOf(multiply), multiplier)
 }
 
+/*
+ func naturaltime(granularity string, t time.Time|int|string) (string, error)
+Describes a time relative to now in words (e.g. "3 hours ago", "in 2 days", "yesterday at 14:00"), using units no
+smaller than `granularity` (`second` by default, or `minute`, `hour`, `day`). The time may be a time.Time, a Unix time
+or a date string (see `parsedate`). May be preceded by a locale (from the `locale` function).
+*/
+func naturaltime(params ...any) (string, error) {
+	sig := "naturaltime(granularity string, t time.Time|int|string)"
+
+	locale, params := splitLocaleArgument(params)
+	granularity := "second"
+	if len(params) == 2 {
+		g, ok := params[0].(string)
+		if !ok {
+			err := logError(sig + " granularity must be a string")
+			return "", err
+		}
+		granularity, params = g, params[1:]
+	}
+	if len(params) != 1 {
+		err := logError(sig + " expects 1 or 2 arguments, received %d", len(params))
+		return "", err
+	}
+
+	t, err := dateValue(params[0])
+	if err != nil {
+		err := logError(sig + " " + err.Error())
+		return "", err
+	}
+
+	text, err := describeRelativeTime(t, time.Now(), granularity, locale)
+	if err != nil {
+		err := logError(sig + " " + err.Error())
+		return "", err
+	}
+
+	return text, nil
+}
+
 /*
  func nl2br[T any](value T) (T, error)
 Replaces all instances of "\n" (new line) with instances of "<br>" within `value`.
@@ -1661,6 +2046,15 @@ func now() (time.Time, error) {
 	return time.Now().In(dateLocalTimezone), nil
 }
 
+/*
+ func number[T any](decimals int, value T) (string, error)
+Formats a number with the decimal and grouping separators of the locale. Without `decimals`, up to 3 decimal places
+are shown (integers are shown exactly).
+*/
+func number(args ...reflect.Value) (string, error) {
+	return numberFormat("en", args...)
+}
+
 /*
  func ol(value any) (string, error)
 Converts slices, arrays or maps into an HTML ordered list.
@@ -1704,6 +2098,45 @@ func ordinal(value reflect.Value) (string, error) {
 	return "", err
 }
 
+/*
+ func parsedate(value string, base time.Time|string) (time.Time, error)
+Parses a date string. It may be an absolute date in a common format (ISO 8601, RFC 3339 / 1123 / 822 / 850, MySQL,
+"2 January 2006", "01/02/2006"...), a relative phrase ("+1 week", "3 days ago", "next monday", "last day of next
+month", "tomorrow noon") or both ("2020-01-31 +1 day"). Relative phrases are applied to `base` (default: now).
+*/
+func parsedate(params ...any) (time.Time, error) {
+	sig := "parsedate(value string, base time.Time|string)"
+
+	base := time.Now().In(dateLocalTimezone)
+	if len(params) < 1 || len(params) > 2 {
+		err := logError(sig + " expects 1 or 2 arguments, received %d", len(params))
+		return base, err
+	}
+
+	value, ok := params[0].(string)
+	if !ok {
+		err := logError(sig + " value must be a string")
+		return base, err
+	}
+
+	if len(params) == 2 {
+		tmp, err := dateValue(params[1])
+		if err != nil {
+			err := logError(sig + " " + err.Error())
+			return base, err
+		}
+		base = tmp
+	}
+
+	t, err := parseDate(value, base)
+	if err != nil {
+		err := logError(sig + " " + err.Error())
+		return base, err
+	}
+
+	return t, nil
+}
+
 /*
  func paragraph[T any](value T) (T, error)
 Replaces all instances of "\n+" (multiple new lines) with paragraphs and instances of "\n" (new line) with instances of "<br>" within `value`
@@ -1719,6 +2152,14 @@ func paragraph(value reflect.Value) (reflect.Value, error) {
 	return value, nil
 }
 
+/*
+ func percent[T any](decimals int, value T) (string, error)
+Formats a ratio (e.g. 0.25) as a percentage in the style of the locale. Without `decimals`, no decimal places are shown.
+*/
+func percent(args ...reflect.Value) (string, error) {
+	return numberPercent("en", args...)
+}
+
 /*
 Allows pluralisation of word endings.
 Allows basic customisation of the possible plural forms.
@@ -2337,6 +2778,28 @@ func split(separator reflect.Value, value reflect.Value) (reflect.Value, error)
 	return reflect.Value{}, err
 }
 
+/*
+ func startof(unit string, t time.Time|string) (time.Time, error)
+Finds the start of the minute, hour, day, week (starting on Monday), month, quarter or year containing `t`.
+*/
+func startof(unit string, t any) (time.Time, error) {
+	sig := "startof(unit string, t time.Time|string)"
+
+	value, err := dateValue(t)
+	if err != nil {
+		err := logError(sig + " " + err.Error())
+		return value, err
+	}
+
+	start, err := startOfDate(value, unit)
+	if err != nil {
+		err := logError(sig + " " + err.Error())
+		return value, err
+	}
+
+	return start, nil
+}
+
 /*
  func startswith(find any, value any) (bool, error)
 Determines if a string starts with a certain value.
@@ -2592,18 +3055,20 @@ It can accept various argument combinations:
 func timeFn(params ...any) (string, error) {
 	sig		:= "time(params ...time.Time|string)"
 	t		:= time.Now()
-	f		:= dateFormatHelper(dateDefaultTimeFormat)
+	f		:= dateDefaultTimeFormat
+
+	locale, params := splitLocaleArgument(params)
 
 	if len(params) == 1 {
 		switch val := params[0].(type) {
 			case time.Time: t = val
-			case string: f = dateFormatHelper(val)
+			case string: f = val
 			case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
 				num, _ := interfaceHelperConvertToInt64(val)
 				t = time.Unix(num, 0)
 		}
 	} else if len(params) == 2 {
-		f = dateFormatHelper(params[0].(string))
+		f = params[0].(string)
 		switch val := params[1].(type)  {
 			case time.Time:
 				t = val
@@ -2619,7 +3084,7 @@ func timeFn(params ...any) (string, error) {
 				t = time.Unix(num, 0)
 		}
 	} else if len(params) > 2 {
-		f = dateFormatHelper(params[0].(string))
+		f = params[0].(string)
 		l := dateFormatHelper(params[1].(string))
 		tmp, err := time.Parse(l, params[2].(string))
 		if err != nil {
@@ -2637,7 +3102,7 @@ func timeFn(params ...any) (string, error) {
 		t = tmp
 	}
 
-	return t.In(dateLocalTimezone).Format(f), nil
+	return formatDate(t.In(dateLocalTimezone), f, locale), nil
 }
 
 /*
@@ -1,7 +1,12 @@
 package templateManager
 
 import (
+	"bytes"
+	HT "html/template"
+	"net/http"
+	"reflect"
 	"testing"
+	"testing/fstest"
 	"time"
 )
 
@@ -44,6 +49,42 @@ func TestAdd(tester *testing.T) {
 	testRunArgTests(add, tests, tester)
 }
 
+func TestCalendar(tester *testing.T) {
+	now		:= time.Date(2020, 2, 14, 9, 0, 0, 0, dateLocalTimezone)
+	events	:= []map[string]any{{"Date": "2020-02-14 19:30", "Name": "Dinner"}, {"Date": now, "Name": "Flowers"}, {"Date": "2020-03-01", "Name": "Trip"}}
+	type booking struct{ Start time.Time; Guest string }
+	bookings := []booking{{time.Date(2020, 2, 3, 0, 0, 0, 0, dateLocalTimezone), "Ann"}}
+
+	monday, _	:= buildCalendar(2020, time.February, time.Monday, reflect.ValueOf(events), "Date", now)
+	sunday, _	:= buildCalendar(2020, time.February, time.Sunday, reflect.ValueOf(bookings), "Start", now)
+	day			:= func(c Calendar, week int, day int) CalendarDay { return c.Weeks[week].Days[day] }
+	date		:= func(d CalendarDay) string { return d.Date.Format("2006-01-02") }
+	fn			:= func(c Calendar, _ error) int { return len(c.Weeks) }
+
+	tests := []struct { inputs []any; result any; expected any } {
+		{ []any{"monday: weeks"}, len(monday.Weeks), 5 },
+		{ []any{"monday: first day"}, date(day(monday, 0, 0)), "2020-01-27" },
+		{ []any{"monday: last day"}, date(day(monday, 4, 6)), "2020-03-01" },
+		{ []any{"monday: week numbers"}, []int{monday.Weeks[0].Number, monday.Weeks[4].Number}, []int{5, 9} },
+		{ []any{"monday: weekday headings"}, []time.Weekday{monday.Weekdays[0].Weekday(), monday.Weekdays[6].Weekday()}, []time.Weekday{time.Monday, time.Sunday} },
+		{ []any{"monday: out of month"}, []bool{day(monday, 0, 4).OutOfMonth, day(monday, 0, 5).OutOfMonth}, []bool{true, false} },
+		{ []any{"monday: weekend"}, []bool{day(monday, 1, 4).Weekend, day(monday, 1, 5).Weekend}, []bool{false, true} },
+		{ []any{"monday: today"}, []bool{day(monday, 2, 4).Today, day(monday, 2, 3).Today}, []bool{true, false} },
+		{ []any{"monday: items"}, []int{len(day(monday, 2, 4).Items), len(day(monday, 4, 6).Items), len(day(monday, 2, 3).Items)}, []int{2, 1, 0} },
+		{ []any{"monday: item order"}, day(monday, 2, 4).Items[0].(map[string]any)["Name"], "Dinner" },
+		{ []any{"sunday: first and last day"}, []string{date(day(sunday, 0, 0)), date(day(sunday, 4, 6))}, []string{"2020-01-26", "2020-02-29"} },
+		{ []any{"sunday: struct items"}, day(sunday, 1, 1).Items, []any{bookings[0]} },
+		{ []any{"sunday: navigation"}, []string{sunday.Previous.Format("2006-01"), sunday.Next.Format("2006-01"), sunday.End.Format("02")}, []string{"2020-01", "2020-03", "29"} },
+		{ []any{2021, 2, "monday"}, fn(calendar(2021, 2, "monday")), 4 },
+		{ []any{2020, time.May, 0, []time.Time{now}}, fn(calendar(2020, time.May, 0, []time.Time{now})), 6 },
+		{ []any{2020, 13, 1}, fn(calendar(2020, 13, 1)), 0 },
+		{ []any{2020, 2, "someday"}, fn(calendar(2020, 2, "someday")), 0 },
+		{ []any{2020, 2, 1, []string{"x"}}, fn(calendar(2020, 2, 1, []string{"x"})), 0 },
+	}
+
+	testRunTests("calendar", tests, tester)
+}
+
 func TestCapfirst(tester *testing.T) {
 	tests := []struct{ input1, expected any } {
 		{ true, true },
@@ -201,6 +242,59 @@ func TestDate(tester *testing.T) {
 	testRunTests("date", tests, tester)
 }
 
+func TestDateAdd(tester *testing.T) {
+	base := time.Date(2020, 1, 31, 10, 30, 0, 0, dateLocalTimezone)
+	fn := func(t time.Time, _ error) string { return t.Format("2006-01-02 15:04:05") }
+
+	tests := []struct { inputs []any; result any; expected any } {
+		{ []any{"1h30m", base}, fn(dateadd("1h30m", base)), "2020-01-31 12:00:00" },
+		{ []any{time.Minute, base}, fn(dateadd(time.Minute, base)), "2020-01-31 10:31:00" },
+		{ []any{3, "days", base}, fn(dateadd(3, "days", base)), "2020-02-03 10:30:00" },
+		{ []any{1, "month", base}, fn(dateadd(1, "month", base)), "2020-03-02 10:30:00" },
+		{ []any{"+1 week 2 days", base}, fn(dateadd("+1 week 2 days", base)), "2020-02-09 10:30:00" },
+		{ []any{"next monday", base}, fn(dateadd("next monday", base)), "2020-02-03 00:00:00" },
+		{ []any{"+1 year", "2020-01-01"}, fn(dateadd("+1 year", "2020-01-01")), "2021-01-01 00:00:00" },
+		{ []any{"invalid", base}, fn(dateadd("invalid", base)), "2020-01-31 10:30:00" },
+		{ []any{2, "fortnights", base}, fn(dateadd(2, "fortnights", base)), "2020-02-28 10:30:00" },
+	}
+
+	testRunTests("dateadd", tests, tester)
+}
+
+func TestDateDiff(tester *testing.T) {
+	from	:= time.Date(2020, 1, 31, 10, 30, 0, 0, dateLocalTimezone)
+	to		:= time.Date(2021, 3, 15, 8, 0, 0, 0, dateLocalTimezone)
+	fn		:= func(n int, _ error) int { return n }
+
+	tests := []struct { inputs []any; result any; expected any } {
+		{ []any{"days", from, to}, fn(datediff("days", from, to)), 408 },
+		{ []any{"weeks", from, to}, fn(datediff("weeks", from, to)), 58 },
+		{ []any{"months", from, to}, fn(datediff("months", from, to)), 13 },
+		{ []any{"quarter", from, to}, fn(datediff("quarter", from, to)), 4 },
+		{ []any{"years", from, to}, fn(datediff("years", from, to)), 1 },
+		{ []any{"months", to, from}, fn(datediff("months", to, from)), -13 },
+		{ []any{"minutes", from, "2020-01-31 12:00"}, fn(datediff("minutes", from, "2020-01-31 12:00")), 90 },
+		{ []any{"months", "2020-01-31", "2020-02-29"}, fn(datediff("months", "2020-01-31", "2020-02-29")), 0 },
+		{ []any{"decades", from, to}, fn(datediff("decades", from, to)), 0 },
+	}
+
+	testRunTests("datediff", tests, tester)
+}
+
+func TestDateSub(tester *testing.T) {
+	base := time.Date(2020, 3, 1, 10, 30, 0, 0, dateLocalTimezone)
+	fn := func(t time.Time, _ error) string { return t.Format("2006-01-02 15:04:05") }
+
+	tests := []struct { inputs []any; result any; expected any } {
+		{ []any{"30m", base}, fn(datesub("30m", base)), "2020-03-01 10:00:00" },
+		{ []any{1, "day", base}, fn(datesub(1, "day", base)), "2020-02-29 10:30:00" },
+		{ []any{"1 year 1 month", base}, fn(datesub("1 year 1 month", base)), "2019-02-01 10:30:00" },
+		{ []any{2, "quarters", base}, fn(datesub(2, "quarters", base)), "2019-09-01 10:30:00" },
+	}
+
+	testRunTests("datesub", tests, tester)
+}
+
 func TestDatetime(tester *testing.T) {
 	currentTime			:= time.Now().In(dateLocalTimezone)
 	testTimeISO8601Z	:= "2019-04-23T11:30:21+01:00"
@@ -419,6 +513,23 @@ func TestDl(tester *testing.T) {
 	testRunArgTests(dl, tests, tester)
 }
 
+func TestEndOf(tester *testing.T) {
+	base := time.Date(2020, 2, 13, 10, 30, 15, 0, dateLocalTimezone)
+	fn := func(t time.Time, _ error) string { return t.Format("2006-01-02 15:04:05.999999999") }
+
+	tests := []struct { inputs []any; result any; expected any } {
+		{ []any{"hour", base}, fn(endof("hour", base)), "2020-02-13 10:59:59.999999999" },
+		{ []any{"day", base}, fn(endof("day", base)), "2020-02-13 23:59:59.999999999" },
+		{ []any{"week", base}, fn(endof("week", base)), "2020-02-16 23:59:59.999999999" },
+		{ []any{"month", base}, fn(endof("month", base)), "2020-02-29 23:59:59.999999999" },
+		{ []any{"quarter", base}, fn(endof("quarter", base)), "2020-03-31 23:59:59.999999999" },
+		{ []any{"year", base}, fn(endof("year", base)), "2020-12-31 23:59:59.999999999" },
+		{ []any{"eon", base}, fn(endof("eon", base)), "2020-02-13 10:30:15" },
+	}
+
+	testRunTests("endof", tests, tester)
+}
+
 func TestEndswith(tester *testing.T) {
 	tests := []struct { input1, input2, expected any } {
 		{ true, "anything", false },
@@ -573,6 +684,7 @@ func TestFirstOf(tester *testing.T) {
 func TestFormatTime(tester *testing.T) {
 	testTimeRFC3339 := "2019-04-23T11:30:21+01:00"
 	testTime, _ := time.Parse(time.RFC3339, testTimeRFC3339)
+	leapTime := time.Date(2020, 2, 29, 15, 4, 5, 123456789, time.FixedZone("CET", 3600))
 
 	fn := func(t string, _ error) string { return t }
 
@@ -580,6 +692,23 @@ func TestFormatTime(tester *testing.T) {
 		{ []any{testTime}, fn(formattime("02/01/2006 15:04", testTime)), testTime.Format("02/01/2006 15:04") },
 		{ []any{testTime}, fn(formattime("d/m/Y H:i", testTime)), testTime.Format("02/01/2006 15:04") },
 		{ []any{testTime}, fn(formattime("%d/%m/%Y %H:%M", testTime)), testTime.Format("02/01/2006 15:04") },
+
+		{ []any{"N w z W t L o", leapTime}, fn(formattime("N w z W t L o", leapTime)), "6 6 59 09 29 1 2020" },
+		{ []any{"jS F Y", leapTime}, fn(formattime("jS F Y", leapTime)), "29th February 2020" },
+		{ []any{"\\T\\o\\d\\a\\y: l", leapTime}, fn(formattime("\\T\\o\\d\\a\\y: l", leapTime)), "Today: Saturday" },
+		{ []any{"H:i:s.u v", leapTime}, fn(formattime("H:i:s.u v", leapTime)), "15:04:05.123456 123" },
+		{ []any{"G g h A a B", leapTime}, fn(formattime("G g h A a B", leapTime)), "15 3 03 PM pm 627" },
+		{ []any{"O P p T Z e I", leapTime}, fn(formattime("O P p T Z e I", leapTime)), "+0100 +01:00 +01:00 CET 3600 CET 0" },
+		{ []any{"c | r | U", leapTime}, fn(formattime("c | r | U", leapTime)), "2020-02-29T15:04:05+01:00 | Sat, 29 Feb 2020 15:04:05 +0100 | 1582985045" },
+		{ []any{"ATOM", leapTime}, fn(formattime("ATOM", leapTime)), "2020-02-29T15:04:05+01:00" },
+		{ []any{"%Y-%m-%d %H:%M:%S.%f", leapTime}, fn(formattime("%Y-%m-%d %H:%M:%S.%f", leapTime)), "2020-02-29 15:04:05.123456" },
+		{ []any{"%-d/%-m %e %j %u %w %U %W %V %G %g %C", leapTime}, fn(formattime("%-d/%-m %e %j %u %w %U %W %V %G %g %C", leapTime)), "29/2 29 060 6 6 08 08 09 2020 20 20" },
+		{ []any{"%I:%M %p %P %k %l", leapTime}, fn(formattime("%I:%M %p %P %k %l", leapTime)), "03:04 PM pm 15  3" },
+		{ []any{"%D %F %R %T %z %:z %Z %%", leapTime}, fn(formattime("%D %F %R %T %z %:z %Z %%", leapTime)), "02/29/20 2020-02-29 15:04 15:04:05 +0100 +01:00 CET %" },
+		{ []any{"%a %A %b %B %h %c", leapTime}, fn(formattime("%a %A %b %B %h %c", leapTime)), "Sat Saturday Feb February Feb Sat Feb 29 15:04:05 2020" },
+
+		{ []any{"Y-m-d\\TH:i:s.uP"}, dateFormatHelper("Y-m-d\\TH:i:s.uP"), "2006-01-02T15:04:05.000000-07:00" },
+		{ []any{"%Y-%m-%d %H:%M:%S.%f"}, dateFormatHelper("%Y-%m-%d %H:%M:%S.%f"), "2006-01-02 15:04:05.000000" },
 	}
 
 	testRunTests("formattime", tests, tester)
@@ -684,6 +813,27 @@ func TestHtmlEncode(tester *testing.T) {
 	testRunArgTests(htmlEncode, tests, tester)
 }
 
+func TestIsWeekday(tester *testing.T) {
+	saturday	:= time.Date(2021, 1, 2, 12, 0, 0, 0, dateLocalTimezone)
+	monday		:= time.Date(2020, 12, 28, 12, 0, 0, 0, dateLocalTimezone)
+	fn			:= func(b bool, _ error) bool { return b }
+	week		:= func(n int, _ error) int { return n }
+
+	tests := []struct { inputs []any; result any; expected any } {
+		{ []any{saturday}, fn(isweekday(saturday)), false },
+		{ []any{monday}, fn(isweekday(monday)), true },
+		{ []any{"sat", "Sunday", saturday}, fn(isweekday("sat", "Sunday", saturday)), true },
+		{ []any{"friday", monday}, fn(isweekday("friday", monday)), false },
+		{ []any{"someday", monday}, fn(isweekday("someday", monday)), false },
+		{ []any{saturday}, fn(isweekend(saturday)), true },
+		{ []any{"2020-12-28"}, fn(isweekend("2020-12-28")), false },
+		{ []any{saturday}, week(isoweek(saturday)), 53 },
+		{ []any{"2021-01-04"}, week(isoweek("2021-01-04")), 1 },
+	}
+
+	testRunTests("isweekday", tests, tester)
+}
+
 func TestIterable(tester *testing.T) {
 	tests := []struct { inputs []any; expected any } {
 		{ []any{}, []int{} },
@@ -1126,6 +1276,47 @@ func TestLtrim(tester *testing.T) {
 	testRunArgTests(ltrim, tests, tester)
 }
 
+func TestMarkdown(tester *testing.T) {
+	fn := func(h HT.HTML, _ error) string { return string(h) }
+
+	tests := []struct { inputs []any; result any; expected any } {
+		{ []any{"# Title"}, fn(markdown("# Title")), "<h1>Title</h1>\n" },
+		{ []any{"Some *text*"}, fn(markdown("Some *text*")), "<p>Some <em>text</em></p>\n" },
+		{ []any{"| A |\n|---|\n| 1 |"}, fn(markdown("| A |\n|---|\n| 1 |")), "<table>\n<thead>\n<tr>\n<th>A</th>\n</tr>\n</thead>\n<tbody>\n<tr>\n<td>1</td>\n</tr>\n</tbody>\n</table>\n" },
+		{ []any{"```go\nx := 1\n```"}, fn(markdown("```go\nx := 1\n```")), "<pre><code class=\"language-go\">x := 1\n</code></pre>\n" },
+		{ []any{"<b>raw</b> [x](javascript:alert(1))"}, fn(markdown("<b>raw</b> [x](javascript:alert(1))")), "<p><!-- raw HTML omitted -->raw<!-- raw HTML omitted --> <a href=\"\">x</a></p>\n" },
+		{ []any{10}, fn(markdown(10)), "<p>10</p>\n" },
+	}
+
+	testRunTests("markdown", tests, tester)
+
+	files := fstest.MapFS{
+		"templates/layouts/main.html":	{Data: []byte(`<title>{{ .Title }}</title>{{ block "content" . }}default{{ end }}`)},
+		"templates/about.md":			{Data: []byte("---\nTitle: About\n---\n{{ extends \"layouts/main.html\" }}\n# {{ .Title }}\n\nHello *{{ .Name }}* ({{ upper \"x\" }})\n\n<div>raw</div>\n")},
+		"templates/plain.md":			{Data: []byte("Just **text**")},
+		"templates/layouts/extra.html":	{Data: []byte(`{{ block "content" . }}{{ end }}|{{ block "extra" . }}{{ end }}`)},
+		"templates/blocks.md":			{Data: []byte("{{ extends \"layouts/extra.html\" }}\n{{ define \"extra\" }}*{{ .Name }}*{{ end }}\n{{ if .Name }}\nHello **{{ .Name }}** {{ print \"}}\" }}\n{{ end }}\n\n```\n{{ if .Name }}\n{{ .Name }}\n{{ end }}\n```\n")},
+	}
+
+	for _, engine := range []string{"text", "html"} {
+		tm := Init("templates", ".html", ".md").TemplateEngine(engine)
+		tm.fileSystem = http.FS(files)
+
+		renders := []struct{ name string; expected string }{
+			{"about.md", "<title>About</title><h1>About</h1>\n<p>Hello <em>Ann</em> (X)</p>\n<div>raw</div>"},
+			{"plain.md", "<p>Just <strong>text</strong></p>\n"},
+			{"blocks.md", "\n<p>Hello <strong>Ann</strong> }}</p>\n\n<pre><code>\nAnn\n\n</code></pre>|*Ann*"},
+		}
+		for _, test := range renders {
+			buf := &bytes.Buffer{}
+			err := tm.Render(test.name, Params{"Name": "Ann"}, buf)
+			if err != nil || buf.String() != test.expected {
+				tester.Errorf("\033[31mFAIL: \033[36mmarkdown template %s (%s)\033[0m:\n\t\033[31mProduced: \033[33m%q (%v)\033[0m\n\t\033[31mExpected: \033[33m%q\033[0m", test.name, engine, buf.String(), err, test.expected)
+			}
+		}
+	}
+}
+
 func TestMd5(tester *testing.T) {
 	tests := []struct { input1, expected any } {
 		{ true, "b326b5062b2f0e69046810717534cb09" },
@@ -1310,6 +1501,34 @@ func TestParagraph(tester *testing.T) {
 	testRunArgTests(paragraph, tests, tester)
 }
 
+func TestParseDate(tester *testing.T) {
+	base := time.Date(2020, 1, 29, 10, 30, 0, 0, dateLocalTimezone)
+	fn := func(t time.Time, _ error) string { return t.UTC().Format("2006-01-02 15:04:05") }
+
+	tests := []struct { inputs []any; result any; expected any } {
+		{ []any{"2019-04-23T11:30:21+01:00"}, fn(parsedate("2019-04-23T11:30:21+01:00")), "2019-04-23 10:30:21" },
+		{ []any{"2019-04-23 11:30"}, fn(parsedate("2019-04-23 11:30")), "2019-04-23 11:30:00" },
+		{ []any{"20190423"}, fn(parsedate("20190423")), "2019-04-23 00:00:00" },
+		{ []any{"Tue, 23 Apr 2019 11:30:21 +0100"}, fn(parsedate("Tue, 23 Apr 2019 11:30:21 +0100")), "2019-04-23 10:30:21" },
+		{ []any{"23 April 2019"}, fn(parsedate("23 April 2019")), "2019-04-23 00:00:00" },
+		{ []any{"@1556015421"}, fn(parsedate("@1556015421")), "2019-04-23 10:30:21" },
+		{ []any{"+1 week", base}, fn(parsedate("+1 week", base)), "2020-02-05 10:30:00" },
+		{ []any{"-2 days 3 hours", base}, fn(parsedate("-2 days 3 hours", base)), "2020-01-27 13:30:00" },
+		{ []any{"3 hours ago", base}, fn(parsedate("3 hours ago", base)), "2020-01-29 07:30:00" },
+		{ []any{"tomorrow noon", base}, fn(parsedate("tomorrow noon", base)), "2020-01-30 12:00:00" },
+		{ []any{"yesterday", base}, fn(parsedate("yesterday", base)), "2020-01-28 00:00:00" },
+		{ []any{"wednesday", base}, fn(parsedate("wednesday", base)), "2020-01-29 00:00:00" },
+		{ []any{"next wednesday", base}, fn(parsedate("next wednesday", base)), "2020-02-05 00:00:00" },
+		{ []any{"last friday", base}, fn(parsedate("last friday", base)), "2020-01-24 00:00:00" },
+		{ []any{"last day of next month", base}, fn(parsedate("last day of next month", base)), "2020-02-29 00:00:00" },
+		{ []any{"first day of this month", base}, fn(parsedate("first day of this month", base)), "2020-01-01 00:00:00" },
+		{ []any{"2020-01-31 +1 day, 2 hours"}, fn(parsedate("2020-01-31 +1 day, 2 hours")), "2020-02-01 02:00:00" },
+		{ []any{"next blue moon", base}, fn(parsedate("next blue moon", base)), "2020-01-29 10:30:00" },
+	}
+
+	testRunTests("parsedate", tests, tester)
+}
+
 func TestPluralise(tester *testing.T) {
 	fn := func(s string, _ error) string { return s }
 
@@ -1595,6 +1814,22 @@ func TestSplit(tester *testing.T) {
 	testRunArgTests(split, tests, tester)
 }
 
+func TestStartOf(tester *testing.T) {
+	base := time.Date(2020, 2, 16, 10, 30, 15, 0, dateLocalTimezone)
+	fn := func(t time.Time, _ error) string { return t.Format("2006-01-02 15:04:05") }
+
+	tests := []struct { inputs []any; result any; expected any } {
+		{ []any{"minute", base}, fn(startof("minute", base)), "2020-02-16 10:30:00" },
+		{ []any{"day", base}, fn(startof("day", base)), "2020-02-16 00:00:00" },
+		{ []any{"week", base}, fn(startof("week", base)), "2020-02-10 00:00:00" },
+		{ []any{"month", base}, fn(startof("month", base)), "2020-02-01 00:00:00" },
+		{ []any{"quarter", base}, fn(startof("quarter", base)), "2020-01-01 00:00:00" },
+		{ []any{"years", "2020-06-15 10:00"}, fn(startof("years", "2020-06-15 10:00")), "2020-01-01 00:00:00" },
+	}
+
+	testRunTests("startof", tests, tester)
+}
+
 func TestStartswith(tester *testing.T) {
 	tests := []struct { input1, input2, expected any } {
 		{ true, "anything", false },
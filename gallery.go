@@ -0,0 +1,310 @@
+package templateManager
+
+/*
+A browsable catalogue of all components, rendered through the normal template pipeline
+*/
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	HT "html/template"
+	"net/http"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/exp/slices"
+
+	"github.com/paul-norman/go-template-manager/fsWalk"
+)
+
+// A single example of a component in use, as declared in its examples file / block
+type componentExample struct {
+	Name		string			`json:"name"`
+	Attributes	map[string]any	`json:"attributes"`
+	Content		string			`json:"content"`
+	Params		map[string]any	`json:"params"`
+}
+
+// A rendered component example, ready for display in the gallery
+type galleryExample struct {
+	Name		string
+	Attributes	[]galleryAttribute
+	Markup		string
+	Output		string
+	Error		string
+}
+
+// A single attribute of a rendered component example
+type galleryAttribute struct {
+	Name	string
+	Value	string
+	Type	string
+}
+
+// A component (and all of the tags that it may be used with) for display in the gallery
+type galleryComponent struct {
+	Path		string
+	Tag			string
+	Tags		[]string
+	Source		string
+	Examples	[]galleryExample
+	Error		string
+}
+
+// Returns an `http.Handler` which lists every component known to the `TemplateManager` and renders each of them
+// with the examples declared beside it (in a `Name.examples.json` file or an `{{ examples }}` block in the file).
+// It is intended for development use only: mount it on a private route.
+func (tm *TemplateManager) ComponentGallery() http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if !tm.parsed {
+			err := tm.Parse()
+			if err != nil {
+				http.Error(writer, err.Error(), http.StatusInternalServerError)
+				return
+			}
+		}
+
+		components	:= tm.galleryComponents()
+		selected	:= request.URL.Query().Get("component")
+		data		:= map[string]any{"Components": components}
+
+		if len(selected) > 0 {
+			index := slices.IndexFunc(components, func(component galleryComponent) bool { return component.Path == selected })
+			if index < 0 {
+				http.NotFound(writer, request)
+				return
+			}
+
+			component := tm.galleryComponent(components[index])
+			data["Component"] = component
+		}
+
+		writer.Header().Set("Content-Type", "text/html; charset=utf-8")
+		err := galleryTemplate.Execute(writer, data)
+		if err != nil {
+			http.Error(writer, err.Error(), http.StatusInternalServerError)
+		}
+	})
+}
+
+// Lists all components (once each, however many tags they have) sorted by path
+func (tm *TemplateManager) galleryComponents() []galleryComponent {
+	tm.mutex.RLock()
+	tags := map[string][]string{}
+	for tag, componentPath := range tm.components {
+		tags[componentPath] = append(tags[componentPath], tag)
+	}
+	tm.mutex.RUnlock()
+
+	components := []galleryComponent{}
+	for componentPath, names := range tags {
+		// The shortest tag is the preferred one (bare if available, otherwise the namespaced form)
+		sort.Slice(names, func(i, j int) bool {
+			if len(names[i]) == len(names[j]) {
+				return names[i] < names[j]
+			}
+			return len(names[i]) < len(names[j])
+		})
+
+		components = append(components, galleryComponent{Path: componentPath, Tag: names[0], Tags: names})
+	}
+
+	sort.Slice(components, func(i, j int) bool { return components[i].Path < components[j].Path })
+
+	return components
+}
+
+// Reads a component's source and renders each of its examples
+func (tm *TemplateManager) galleryComponent(component galleryComponent) galleryComponent {
+	buffer, err := fsWalk.ReadFile(tm.directory + "/" + component.Path, tm.fileSystem)
+	if err != nil {
+		component.Error = err.Error()
+		return component
+	}
+	component.Source = string(buffer)
+
+	examples, err := tm.componentExamples(component.Path, component.Source)
+	if err != nil {
+		component.Error = err.Error()
+	}
+
+	for _, example := range examples {
+		component.Examples = append(component.Examples, tm.renderComponentExample(component.Tag, example))
+	}
+
+	return component
+}
+
+// Finds the examples declared for a component, either in a `Name.examples.json` file beside it, or in an
+// `{{ examples }}` block within the component itself. Components without examples get a single empty example.
+func (tm *TemplateManager) componentExamples(componentPath string, source string) ([]componentExample, error) {
+	examples	:= []componentExample{}
+	declared	:= ""
+	name		:= componentVariableName(componentPath)
+	file		:= tm.directory + "/" + path.Dir(componentPath) + "/" + name + ".examples.json"
+
+	if buffer, err := fsWalk.ReadFile(file, tm.fileSystem); err == nil {
+		declared = string(buffer)
+	} else if match := regexps["findComponentExamples"].FindStringSubmatch(source); match != nil {
+		declared = match[1]
+	}
+
+	if len(strings.TrimSpace(declared)) == 0 {
+		return []componentExample{{Name: "Default"}}, nil
+	}
+
+	err := json.Unmarshal([]byte(declared), &examples)
+	if err != nil {
+		return []componentExample{{Name: "Default"}}, fmt.Errorf("invalid examples for %s: %s", componentPath, err.Error())
+	}
+
+	for i := range examples {
+		if len(examples[i].Name) == 0 {
+			examples[i].Name = "Example " + strconv.Itoa(i + 1)
+		}
+	}
+
+	return examples, nil
+}
+
+// Renders a single component example through the normal component and template pipeline, with the params that a
+// page at the root of the templates directory would have (globals, directory params and `.Data`)
+func (tm *TemplateManager) renderComponentExample(tag string, example componentExample) galleryExample {
+	rendered	:= galleryExample{Name: example.Name}
+	params		:= Params{}
+	markup		:= "<" + tag
+
+	for name, value := range example.Params {
+		params[name] = value
+	}
+
+	names := []string{}
+	for name := range example.Attributes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	attributes := map[string]any{}
+	for _, name := range names {
+		value := example.Attributes[name]
+
+		switch typed := value.(type) {
+			case string:
+				markup += ` ` + name + `="` + HT.HTMLEscapeString(typed) + `"`
+			case float64:
+				markup += ` ` + name + `=` + strconv.FormatFloat(typed, 'f', -1, 64)
+			default:
+				// Anything that cannot be written as an attribute is passed in as data instead
+				attributes[name] = value
+				markup += ` ` + name + `="` + tm.delimiterLeft + ` .ExampleAttributes.` + name + ` ` + tm.delimiterRight + `"`
+		}
+
+		rendered.Attributes = append(rendered.Attributes, galleryAttribute{Name: name, Value: fmt.Sprintf("%v", value), Type: fmt.Sprintf("%T", value)})
+	}
+	params["ExampleAttributes"] = attributes
+
+	params, err := tm.buildParams("", params, nil)
+	if err != nil {
+		rendered.Error = err.Error()
+		return rendered
+	}
+
+	if len(example.Content) > 0 {
+		markup += ">" + example.Content + "</" + tag + ">"
+	} else {
+		markup += " />"
+	}
+	rendered.Markup = markup
+
+	tm.mutex.Lock()
+	content := tm.parseContentComponents(tm.delimiterLeft + " componentStyles " + tm.delimiterRight + markup + tm.delimiterLeft + " componentScripts " + tm.delimiterRight, tm.directory)
+	tm.mutex.Unlock()
+
+	tmpl := tm.configureNewTemplate(NewTemplate(tm.templateType, "gallery"))
+	_, err = tmpl.Parse(content)
+	if err != nil {
+		rendered.Error = err.Error()
+		return rendered
+	}
+
+	buf := &bytes.Buffer{}
+	err = tmpl.Execute(buf, params)
+	if err != nil {
+		rendered.Error = err.Error()
+		return rendered
+	}
+
+	rendered.Output = string(tm.injectComponentAssets(buf.Bytes()))
+
+	return rendered
+}
+
+var galleryTemplate = HT.Must(HT.New("gallery").Funcs(HT.FuncMap{
+	"trusted": func(html string) HT.HTML { return HT.HTML(html) },
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+	<meta charset="utf-8">
+	<title>Component Gallery{{ with .Component }} - {{ .Tag }}{{ end }}</title>
+	<style>
+		body { display: flex; margin: 0; font-family: system-ui, sans-serif; color: #222; }
+		nav { flex: 0 0 16rem; min-height: 100vh; padding: 1rem; background: #f4f4f5; box-sizing: border-box; }
+		nav a { display: block; padding: .25rem 0; color: #1d4ed8; text-decoration: none; }
+		nav a.active { font-weight: bold; }
+		main { flex: 1; padding: 1rem 2rem; min-width: 0; }
+		pre { padding: 1rem; overflow: auto; background: #18181b; color: #f4f4f5; border-radius: .25rem; }
+		table { border-collapse: collapse; margin-bottom: 1rem; }
+		th, td { padding: .25rem .75rem; border: 1px solid #d4d4d8; text-align: left; }
+		.example { margin-bottom: 3rem; }
+		.preview { padding: 1rem; border: 1px dashed #a1a1aa; border-radius: .25rem; }
+		.error { color: #b91c1c; }
+		small { color: #71717a; }
+	</style>
+</head>
+<body>
+	<nav>
+		<h2>Components</h2>
+		{{- $selected := "" }}{{ with .Component }}{{ $selected = .Path }}{{ end }}
+		{{- range .Components }}
+		<a href="?component={{ .Path }}"{{ if eq .Path $selected }} class="active"{{ end }}>{{ .Tag }}</a>
+		{{- else }}
+		<p>No components found.</p>
+		{{- end }}
+	</nav>
+	<main>
+	{{- with .Component }}
+		<h1>&lt;{{ .Tag }}&gt;</h1>
+		<p><small>{{ .Path }} &mdash; {{ range $i, $tag := .Tags }}{{ if $i }}, {{ end }}&lt;{{ $tag }}&gt;{{ end }}</small></p>
+		{{- with .Error }}<p class="error">{{ . }}</p>{{ end }}
+		{{- range .Examples }}
+		<section class="example">
+			<h2>{{ .Name }}</h2>
+			{{- if .Attributes }}
+			<table>
+				<tr><th>Attribute</th><th>Value</th><th>Type</th></tr>
+				{{- range .Attributes }}
+				<tr><td>{{ .Name }}</td><td>{{ .Value }}</td><td>{{ .Type }}</td></tr>
+				{{- end }}
+			</table>
+			{{- end }}
+			<pre>{{ .Markup }}</pre>
+			{{- if .Error }}
+			<p class="error">{{ .Error }}</p>
+			{{- else }}
+			<div class="preview">{{ trusted .Output }}</div>
+			<pre>{{ .Output }}</pre>
+			{{- end }}
+		</section>
+		{{- end }}
+		<h2>Source</h2>
+		<pre>{{ .Source }}</pre>
+	{{- else }}
+		<h1>Component Gallery</h1>
+		<p>Choose a component to see its examples, source and rendered output.</p>
+	{{- end }}
+	</main>
+</body>
+</html>`))
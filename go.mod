@@ -3,7 +3,10 @@ module github.com/paul-norman/go-template-manager
 go 1.19
 
 require (
+	github.com/BurntSushi/toml v1.3.2
 	github.com/google/uuid v1.3.0
 	github.com/grokify/html-strip-tags-go v0.0.1
+	github.com/yuin/goldmark v1.5.6
 	golang.org/x/exp v0.0.0-20221126150942-6ab00d035af9
+	gopkg.in/yaml.v3 v3.0.1
 )
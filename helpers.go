@@ -93,99 +93,73 @@ func divideHelper(roundMethod reflect.Value, divisor reflect.Value, value reflec
 }
 
 /*
-A helper that converts Python and PHP date formats to Go format
+A helper that converts Python (strftime) and PHP date formats to Go layouts (used to parse dates).
+Tokens which have no Go equivalent are left as they are.
 */
 func dateFormatHelper(date string) string {
-	if !strings.Contains(date, "06") {
+	if dateFormatIsLayout(date) {
+		return date
+	}
 
-		// Convenience formats
+	layout := strings.Builder{}
+	for _, part := range dateFormatParts(date) {
+		if part.token != nil && len(part.token.layout) > 0 {
+			layout.WriteString(part.token.layout)
+		} else {
+			layout.WriteString(part.source)
+		}
+	}
 
-		// Must be separate otherwise replacer will remove the shorter versions leaving Z characters
-		replacements := map[string]string{
-			"ISO8601Z": "X-m-d\\TH:i:sP",   // "2006-01-02T15:04:05Z07:00"
-			"RFC1123Z": "D, d M Y H:i:s O", // "Mon, 02 Jan 2006 15:04:05 -07:00"
-			"RFC822Z": "D, d M y H:i:s O",  // "Mon, 02 Jan 06 15:04:05 -07:00" should be?: "02 Jan 06 15:04 -07:00" ??
+	return layout.String()
+}
+
+/*
+A helper that powers `dateadd` and `datesub` (`sign` is 1 to add and -1 to subtract).
+*/
+func dateArithmeticHelper(sig string, sign int, params []any) (time.Time, error) {
+	if len(params) < 2 || len(params) > 3 {
+		err := logError(sig + " expects 2 or 3 arguments, received %d", len(params))
+		return time.Time{}, err
+	}
+
+	t, err := dateValue(params[len(params) - 1])
+	if err != nil {
+		err := logError(sig + " " + err.Error())
+		return t, err
+	}
+
+	if len(params) == 3 {
+		amount, err := interfaceHelperConvertToInt(params[0])
+		if err != nil {
+			err := logError(sig + " amount must be a number")
+			return t, err
 		}
-		replacer, _ := replaceHelper(replacements)
-		date = replacer.Replace(date)
-
-		replacements = map[string]string{
-			"ISO8601": 	"Y-m-d\\TH:i:sO",	// "2006-01-02T15:04:05-07:00"
-			"RFC822": 	"D, d M y H:i:s T", // "Mon, 02 Jan 06 15:04:05 MST" should be?: "02 Jan 06 15:04 MST" ??
-			"RFC850": 	"l, d-M-y H:i:s T", // "Monday, 02-Jan-06 15:04:05 MST"
-			"RFC1036": 	"D, d M y H:i:s O",	// "02 Jan 06 15:04 -07:00"
-			"RFC1123": 	"D, d M Y H:i:s T", // "Mon, 02 Jan 2006 15:04:05 MST"
-			"RFC2822": 	"D, d M Y H:i:s O",	// "Mon, 02 Jan 2006 15:04:05 -07:00"
-			"RFC3339": 	"Y-m-d\\TH:i:sP", 	// "2006-01-02T15:04:05Z07:00"
-			"W3C": 		"Y-m-d\\TH:i:sP",	// "2006-01-02T15:04:05Z07:00"
-			"ATOM": 	"Y-m-d\\TH:i:sP",	// "2006-01-02T15:04:05Z07:00"
-			"COOKIE": 	"l, d-M-Y H:i:s T",	// "Monday, 02-Jan-2006 15:04:05 MST"
-			"RSS": 		"D, d M Y H:i:s O",	// "Mon, 02 01 2006 15:04:05 -07:00"
-			"MYSQL": 	"Y-m-d H:i:s",		// "2006-01-02 15:04:05"
-			"UNIX": 	"D M _j H:i:s T Y", // "Mon Jan _2 15:04:05 MST 2006"
-			"RUBY": 	"D M d H:i:s o Y", 	// "Mon Jan 02 15:04:05 -0700 2006"
-			"ANSIC": 	"D M _j H:i:s Y", 	// "Mon Jan _2 15:04:05 2006"
+		name, _ := params[1].(string)
+		unit, err := dateUnit(name)
+		if err != nil {
+			err := logError(sig + " " + err.Error())
+			return t, err
 		}
-		replacer, _ = replaceHelper(replacements)
-		date = replacer.Replace(date)
-
-		if strings.Contains(date, "%") {
-			// Python syntax support
-			replacements = map[string]string{
-				"%Y": "2006",
-				"%y": "06",
-				"%d": "02",
-				"%I": "3",
-				"%H": "15",
-				"%M": "04",
-				"%S": "05",
-				"%m": "01",
-				"%p": "PM",
-				"%b": "Jan",
-				"%B": "January",
-				"%a": "Mon",
-				"%A": "Monday",
-				"%Z": "MST",
-				"%z": "-07:00",
-				"%f": "000",	// what is GO's microseconds?
+		return addDateUnit(t, unit, amount * sign), nil
+	}
+
+	switch amount := params[0].(type) {
+		case time.Duration:
+			return t.Add(amount * time.Duration(sign)), nil
+		case string:
+			if duration, err := time.ParseDuration(amount); err == nil {
+				return t.Add(duration * time.Duration(sign)), nil
 			}
-		} else {
-			// PHP syntax support
-			date = strings.ReplaceAll(date, "\\T", "@1")
-
-			replacements = map[string]string{
-				"Y": "2006",
-				"y": "06",
-				"d": "02",
-				"j": "2",
-				"g": "3",
-				"H": "15",
-				"i": "04",
-				"s": "05",
-				"n": "1",
-				"m": "01",
-				"a": "pm",
-				"A": "PM",
-				"M": "Jan",
-				"F": "January",
-				"D": "Mon",
-				"l": "Monday",
-				"T": "MST",
-				"t": "-07:00",
-				"P": "Z07:00",
-				"O": "-07:00",
-				"o": "-0700",
-				"v": "000",		// what is GO's microseconds?
-				"X": "2006",	// What even is this?
+			moved, err := relativeDate(amount, t, sign)
+			if err != nil {
+				err := logError(sig + " " + err.Error())
+				return t, err
 			}
-		}
-
-		replacer, _ = replaceHelper(replacements)
-		date = replacer.Replace(date)
-		date = strings.ReplaceAll(date, "@1", "T")
+			return moved, nil
 	}
 
-	return date
+	err = logError(sig + " can't use type %T as an amount", params[0])
+	return t, err
 }
 
 /*
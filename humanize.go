@@ -0,0 +1,353 @@
+package templateManager
+
+/*
+Functions dedicated to describing times and durations in words ("3 hours ago", "in 2 days", "1 hour, 5 minutes")
+*/
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/exp/slices"
+)
+
+// The words used to describe times and durations in a locale. Units are keyed by unit then CLDR plural category,
+// with `{0}` marking the number. Languages whose relative times change the unit's case (German "vor 3 Tagen") give
+// those forms in `relative`
+type relativeWords struct {
+	units		map[string]map[string]string
+	relative	map[string]map[string]string
+	past		string
+	future		string
+	now			string
+	yesterday	string
+	today		string
+	tomorrow	string
+	at			string
+}
+
+// The units of relative times, smallest first
+var relativeUnits = []string{"second", "minute", "hour", "day", "week", "month", "year"}
+
+// Creates the forms of a unit which has only "one" and "other" forms
+func relativeUnit(one string, other string) map[string]string {
+	return map[string]string{"one": one, "other": other}
+}
+
+// The words of each supported locale (others use their language's words, or English)
+var relativeLocales = map[string]relativeWords{
+	"en": {
+		units: map[string]map[string]string{
+			"second":	relativeUnit("{0} second", "{0} seconds"),
+			"minute":	relativeUnit("{0} minute", "{0} minutes"),
+			"hour":		relativeUnit("{0} hour", "{0} hours"),
+			"day":		relativeUnit("{0} day", "{0} days"),
+			"week":		relativeUnit("{0} week", "{0} weeks"),
+			"month":	relativeUnit("{0} month", "{0} months"),
+			"year":		relativeUnit("{0} year", "{0} years"),
+		},
+		past: "{0} ago", future: "in {0}", now: "just now", yesterday: "yesterday", today: "today", tomorrow: "tomorrow", at: "{0} at {1}",
+	},
+	"da": {
+		units: map[string]map[string]string{
+			"second":	relativeUnit("{0} sekund", "{0} sekunder"),
+			"minute":	relativeUnit("{0} minut", "{0} minutter"),
+			"hour":		relativeUnit("{0} time", "{0} timer"),
+			"day":		relativeUnit("{0} dag", "{0} dage"),
+			"week":		relativeUnit("{0} uge", "{0} uger"),
+			"month":	relativeUnit("{0} måned", "{0} måneder"),
+			"year":		relativeUnit("{0} år", "{0} år"),
+		},
+		past: "for {0} siden", future: "om {0}", now: "lige nu", yesterday: "i går", today: "i dag", tomorrow: "i morgen", at: "{0} kl. {1}",
+	},
+	"de": {
+		units: map[string]map[string]string{
+			"second":	relativeUnit("{0} Sekunde", "{0} Sekunden"),
+			"minute":	relativeUnit("{0} Minute", "{0} Minuten"),
+			"hour":		relativeUnit("{0} Stunde", "{0} Stunden"),
+			"day":		relativeUnit("{0} Tag", "{0} Tage"),
+			"week":		relativeUnit("{0} Woche", "{0} Wochen"),
+			"month":	relativeUnit("{0} Monat", "{0} Monate"),
+			"year":		relativeUnit("{0} Jahr", "{0} Jahre"),
+		},
+		relative: map[string]map[string]string{
+			"day":		relativeUnit("{0} Tag", "{0} Tagen"),
+			"month":	relativeUnit("{0} Monat", "{0} Monaten"),
+			"year":		relativeUnit("{0} Jahr", "{0} Jahren"),
+		},
+		past: "vor {0}", future: "in {0}", now: "gerade eben", yesterday: "gestern", today: "heute", tomorrow: "morgen", at: "{0} um {1}",
+	},
+	"es": {
+		units: map[string]map[string]string{
+			"second":	relativeUnit("{0} segundo", "{0} segundos"),
+			"minute":	relativeUnit("{0} minuto", "{0} minutos"),
+			"hour":		relativeUnit("{0} hora", "{0} horas"),
+			"day":		relativeUnit("{0} día", "{0} días"),
+			"week":		relativeUnit("{0} semana", "{0} semanas"),
+			"month":	relativeUnit("{0} mes", "{0} meses"),
+			"year":		relativeUnit("{0} año", "{0} años"),
+		},
+		past: "hace {0}", future: "dentro de {0}", now: "ahora mismo", yesterday: "ayer", today: "hoy", tomorrow: "mañana", at: "{0} a las {1}",
+	},
+	"fr": {
+		units: map[string]map[string]string{
+			"second":	relativeUnit("{0} seconde", "{0} secondes"),
+			"minute":	relativeUnit("{0} minute", "{0} minutes"),
+			"hour":		relativeUnit("{0} heure", "{0} heures"),
+			"day":		relativeUnit("{0} jour", "{0} jours"),
+			"week":		relativeUnit("{0} semaine", "{0} semaines"),
+			"month":	relativeUnit("{0} mois", "{0} mois"),
+			"year":		relativeUnit("{0} an", "{0} ans"),
+		},
+		past: "il y a {0}", future: "dans {0}", now: "à l’instant", yesterday: "hier", today: "aujourd’hui", tomorrow: "demain", at: "{0} à {1}",
+	},
+	"it": {
+		units: map[string]map[string]string{
+			"second":	relativeUnit("{0} secondo", "{0} secondi"),
+			"minute":	relativeUnit("{0} minuto", "{0} minuti"),
+			"hour":		relativeUnit("{0} ora", "{0} ore"),
+			"day":		relativeUnit("{0} giorno", "{0} giorni"),
+			"week":		relativeUnit("{0} settimana", "{0} settimane"),
+			"month":	relativeUnit("{0} mese", "{0} mesi"),
+			"year":		relativeUnit("{0} anno", "{0} anni"),
+		},
+		past: "{0} fa", future: "tra {0}", now: "proprio ora", yesterday: "ieri", today: "oggi", tomorrow: "domani", at: "{0} alle {1}",
+	},
+	"nb": {
+		units: map[string]map[string]string{
+			"second":	relativeUnit("{0} sekund", "{0} sekunder"),
+			"minute":	relativeUnit("{0} minutt", "{0} minutter"),
+			"hour":		relativeUnit("{0} time", "{0} timer"),
+			"day":		relativeUnit("{0} dag", "{0} dager"),
+			"week":		relativeUnit("{0} uke", "{0} uker"),
+			"month":	relativeUnit("{0} måned", "{0} måneder"),
+			"year":		relativeUnit("{0} år", "{0} år"),
+		},
+		past: "for {0} siden", future: "om {0}", now: "akkurat nå", yesterday: "i går", today: "i dag", tomorrow: "i morgen", at: "{0} kl. {1}",
+	},
+	"nl": {
+		units: map[string]map[string]string{
+			"second":	relativeUnit("{0} seconde", "{0} seconden"),
+			"minute":	relativeUnit("{0} minuut", "{0} minuten"),
+			"hour":		relativeUnit("{0} uur", "{0} uur"),
+			"day":		relativeUnit("{0} dag", "{0} dagen"),
+			"week":		relativeUnit("{0} week", "{0} weken"),
+			"month":	relativeUnit("{0} maand", "{0} maanden"),
+			"year":		relativeUnit("{0} jaar", "{0} jaar"),
+		},
+		past: "{0} geleden", future: "over {0}", now: "zojuist", yesterday: "gisteren", today: "vandaag", tomorrow: "morgen", at: "{0} om {1}",
+	},
+	"pl": {
+		units: map[string]map[string]string{
+			"second":	{"one": "{0} sekunda", "few": "{0} sekundy", "many": "{0} sekund", "other": "{0} sekundy"},
+			"minute":	{"one": "{0} minuta", "few": "{0} minuty", "many": "{0} minut", "other": "{0} minuty"},
+			"hour":		{"one": "{0} godzina", "few": "{0} godziny", "many": "{0} godzin", "other": "{0} godziny"},
+			"day":		{"one": "{0} dzień", "few": "{0} dni", "many": "{0} dni", "other": "{0} dnia"},
+			"week":		{"one": "{0} tydzień", "few": "{0} tygodnie", "many": "{0} tygodni", "other": "{0} tygodnia"},
+			"month":	{"one": "{0} miesiąc", "few": "{0} miesiące", "many": "{0} miesięcy", "other": "{0} miesiąca"},
+			"year":		{"one": "{0} rok", "few": "{0} lata", "many": "{0} lat", "other": "{0} roku"},
+		},
+		relative: map[string]map[string]string{
+			"second":	{"one": "{0} sekundę", "few": "{0} sekundy", "many": "{0} sekund", "other": "{0} sekundy"},
+			"minute":	{"one": "{0} minutę", "few": "{0} minuty", "many": "{0} minut", "other": "{0} minuty"},
+			"hour":		{"one": "{0} godzinę", "few": "{0} godziny", "many": "{0} godzin", "other": "{0} godziny"},
+		},
+		past: "{0} temu", future: "za {0}", now: "przed chwilą", yesterday: "wczoraj", today: "dzisiaj", tomorrow: "jutro", at: "{0} o {1}",
+	},
+	"pt": {
+		units: map[string]map[string]string{
+			"second":	relativeUnit("{0} segundo", "{0} segundos"),
+			"minute":	relativeUnit("{0} minuto", "{0} minutos"),
+			"hour":		relativeUnit("{0} hora", "{0} horas"),
+			"day":		relativeUnit("{0} dia", "{0} dias"),
+			"week":		relativeUnit("{0} semana", "{0} semanas"),
+			"month":	relativeUnit("{0} mês", "{0} meses"),
+			"year":		relativeUnit("{0} ano", "{0} anos"),
+		},
+		past: "há {0}", future: "em {0}", now: "agora mesmo", yesterday: "ontem", today: "hoje", tomorrow: "amanhã", at: "{0} às {1}",
+	},
+	"ru": {
+		units: map[string]map[string]string{
+			"second":	{"one": "{0} секунда", "few": "{0} секунды", "many": "{0} секунд", "other": "{0} секунды"},
+			"minute":	{"one": "{0} минута", "few": "{0} минуты", "many": "{0} минут", "other": "{0} минуты"},
+			"hour":		{"one": "{0} час", "few": "{0} часа", "many": "{0} часов", "other": "{0} часа"},
+			"day":		{"one": "{0} день", "few": "{0} дня", "many": "{0} дней", "other": "{0} дня"},
+			"week":		{"one": "{0} неделя", "few": "{0} недели", "many": "{0} недель", "other": "{0} недели"},
+			"month":	{"one": "{0} месяц", "few": "{0} месяца", "many": "{0} месяцев", "other": "{0} месяца"},
+			"year":		{"one": "{0} год", "few": "{0} года", "many": "{0} лет", "other": "{0} года"},
+		},
+		relative: map[string]map[string]string{
+			"second":	{"one": "{0} секунду", "few": "{0} секунды", "many": "{0} секунд", "other": "{0} секунды"},
+			"minute":	{"one": "{0} минуту", "few": "{0} минуты", "many": "{0} минут", "other": "{0} минуты"},
+			"week":		{"one": "{0} неделю", "few": "{0} недели", "many": "{0} недель", "other": "{0} недели"},
+		},
+		past: "{0} назад", future: "через {0}", now: "только что", yesterday: "вчера", today: "сегодня", tomorrow: "завтра", at: "{0} в {1}",
+	},
+	"sv": {
+		units: map[string]map[string]string{
+			"second":	relativeUnit("{0} sekund", "{0} sekunder"),
+			"minute":	relativeUnit("{0} minut", "{0} minuter"),
+			"hour":		relativeUnit("{0} timme", "{0} timmar"),
+			"day":		relativeUnit("{0} dag", "{0} dagar"),
+			"week":		relativeUnit("{0} vecka", "{0} veckor"),
+			"month":	relativeUnit("{0} månad", "{0} månader"),
+			"year":		relativeUnit("{0} år", "{0} år"),
+		},
+		past: "för {0} sedan", future: "om {0}", now: "just nu", yesterday: "igår", today: "idag", tomorrow: "imorgon", at: "{0} kl. {1}",
+	},
+}
+
+// Finds the relative time words of a locale: its own, its language's or, failing those, English. Returns the locale
+// whose words were found (to choose plural forms)
+func relativeLocale(locale string) (relativeWords, string) {
+	locale = normaliseLocale(locale)
+	if words, ok := relativeLocales[locale]; ok {
+		return words, locale
+	}
+
+	language, _, _ := strings.Cut(locale, "-")
+	if alias, ok := dateLocaleAliases[language]; ok {
+		language = alias
+	}
+	if words, ok := relativeLocales[language]; ok {
+		return words, language
+	}
+
+	return relativeLocales["en"], "en"
+}
+
+// Describes a number of a unit (e.g. "3 hours"), using the relative form of the unit if `relative`
+func (w relativeWords) count(locale string, unit string, count int64, relative bool) string {
+	forms := w.units[unit]
+	if relative && w.relative[unit] != nil {
+		forms = w.relative[unit]
+	}
+
+	operands, _ := newPluralOperands(count)
+	text, ok := forms[pluralCategory(locale, operands)]
+	if !ok {
+		text = forms["other"]
+	}
+
+	return strings.Replace(text, "{0}", numberLocale(locale).formatDigits(strconv.FormatInt(count, 10)), 1)
+}
+
+// Places a description into the past or future pattern of the locale
+func (w relativeWords) direction(text string, past bool) string {
+	if past {
+		return strings.Replace(w.past, "{0}", text, 1)
+	}
+
+	return strings.Replace(w.future, "{0}", text, 1)
+}
+
+// Checks that a granularity is one of the allowed units, returning its position within `relativeUnits`
+func relativeGranularity(granularity string, allowed []string) (int, error) {
+	if !slices.Contains(allowed, granularity) {
+		return 0, fmt.Errorf("granularity must be one of: %s", strings.Join(allowed, ", "))
+	}
+
+	return slices.Index(relativeUnits, granularity), nil
+}
+
+// Describes `t` relative to `now` in a locale (e.g. "3 hours ago"), showing units no smaller than `granularity`
+func describeRelativeTime(t time.Time, now time.Time, granularity string, locale string) (string, error) {
+	smallest, err := relativeGranularity(granularity, relativeUnits[:4])
+	if err != nil {
+		return "", err
+	}
+
+	words, wordsLocale := relativeLocale(locale)
+	t, now = t.In(dateLocalTimezone), now.In(dateLocalTimezone)
+
+	difference	:= now.Sub(t)
+	past		:= difference >= 0
+	if !past {
+		difference = -difference
+	}
+
+	year, month, day := t.Date()
+	nowYear, nowMonth, nowDay := now.Date()
+	days := int64(math.Round(time.Date(nowYear, nowMonth, nowDay, 0, 0, 0, 0, time.UTC).Sub(time.Date(year, month, day, 0, 0, 0, 0, time.UTC)).Hours() / 24))
+	if days < 0 {
+		days = -days
+	}
+
+	describe := func(unit string, count int64) string {
+		return words.direction(words.count(wordsLocale, unit, count, true), past)
+	}
+
+	switch {
+		case smallest == 0 && difference < 10 * time.Second,
+		smallest == 1 && difference < time.Minute,
+		smallest == 2 && difference < time.Hour:
+			return words.now, nil
+		case smallest == 0 && difference < time.Minute:
+			return describe("second", int64(difference / time.Second)), nil
+		case smallest <= 1 && difference < time.Hour:
+			return describe("minute", int64(difference / time.Minute)), nil
+		case smallest <= 2 && days == 0:
+			return describe("hour", int64(difference / time.Hour)), nil
+		case days == 0:
+			return words.today, nil
+		case days == 1:
+			relative := words.tomorrow
+			if past {
+				relative = words.yesterday
+			}
+			if smallest == 3 {
+				return relative, nil
+			}
+			return strings.NewReplacer("{0}", relative, "{1}", formatDate(t, dateDefaultTimeFormat, locale)).Replace(words.at), nil
+		case days < 7:
+			return describe("day", days), nil
+		case days < 30:
+			return describe("week", days / 7), nil
+		case days < 365:
+			months := int64(nowYear - year) * 12 + int64(nowMonth - month)
+			if months < 0 {
+				months = -months
+			}
+			if months < 1 {
+				months = 1
+			}
+			return describe("month", months), nil
+	}
+
+	return describe("year", days / 365), nil
+}
+
+// Describes a duration in a locale (e.g. "1 hour, 5 minutes"), showing units no smaller than `granularity`
+func describeDuration(duration time.Duration, granularity string, locale string) (string, error) {
+	allowed := []string{"second", "minute", "hour", "day", "week", "year"}
+	smallest, err := relativeGranularity(granularity, allowed)
+	if err != nil {
+		return "", err
+	}
+
+	words, wordsLocale := relativeLocale(locale)
+	if duration < 0 {
+		duration = -duration
+	}
+
+	parts, _ := formatDuration(duration)
+	descriptions := []string{}
+	for i := len(allowed) - 1; i >= 0; i-- {
+		unit := allowed[i]
+		if slices.Index(relativeUnits, unit) < smallest {
+			break
+		}
+		if count := parts[unit + "s"]; count > 0 {
+			descriptions = append(descriptions, words.count(wordsLocale, unit, int64(count), false))
+		}
+	}
+
+	if len(descriptions) == 0 {
+		return words.count(wordsLocale, granularity, 0, false), nil
+	}
+
+	return strings.Join(descriptions, ", "), nil
+}
@@ -0,0 +1,722 @@
+package templateManager
+
+/*
+Functions dedicated to translating templates using message catalogs (JSON, YAML and gettext .po files)
+*/
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/exp/slices"
+
+	"github.com/paul-norman/go-template-manager/fsWalk"
+)
+
+// The top level key of a JSON or YAML catalog under which `tmextract` keeps obsolete messages (they are not loaded)
+const ObsoleteMessagesKey = "#obsolete"
+
+// A single translated message: its text and, for plural messages, its text for each CLDR plural category
+type translationMessage struct {
+	text	string
+	plurals	map[string]string
+}
+
+// All translated messages for a single locale, keyed by message key
+type translationCatalog map[string]translationMessage
+
+// The context key used by `WithLocale()`
+type localeContextKey struct{}
+
+// Returns a copy of `ctx` which sets the locale used by `RenderRequest()` (e.g. from middleware that reads a cookie,
+// URL prefix or `Accept-Language` header)
+func WithLocale(ctx context.Context, locale string) context.Context {
+	return context.WithValue(ctx, localeContextKey{}, locale)
+}
+
+// Finds the locale set on a request's context by `WithLocale()`
+func requestLocale(request *http.Request) (string, bool) {
+	if request == nil {
+		return "", false
+	}
+
+	locale, ok := request.Context().Value(localeContextKey{}).(string)
+
+	return locale, ok && len(locale) > 0
+}
+
+// Converts a locale to the form used to store catalogs (e.g. "en_GB" becomes "en-gb")
+func normaliseLocale(locale string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(locale), "_", "-"))
+}
+
+// Reads every catalog within the translations directory. Catalogs are named after their locale (`fr.json`, `fr.po`)
+// or placed in a directory named after it (`fr/checkout.yaml`), in which case all of its files are merged
+func (tm *TemplateManager) loadTranslations() error {
+	translations := map[string]translationCatalog{}
+
+	if len(tm.translationsDirectory) == 0 {
+		tm.translations = translations
+		return nil
+	}
+
+	walk := func(path string, info fs.DirEntry, err error) error {
+		if err != nil || info == nil {
+			if path == tm.translationsDirectory && errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+
+		if info.IsDir() {
+			return nil
+		}
+
+		format := translationFormat(path)
+		if len(format) == 0 {
+			return nil
+		}
+
+		name, _			:= cleanPath(path, tm.translationsDirectory)
+		locale, _, _	:= strings.Cut(name, "/")
+		if locale == name {
+			locale = strings.TrimSuffix(name, filepath.Ext(name))
+		}
+		locale = normaliseLocale(locale)
+
+		buffer, err := fsWalk.ReadFile(path, tm.fileSystem)
+		if err != nil {
+			return err
+		}
+
+		catalog, err := parseTranslationFile(format, locale, buffer)
+		if err != nil {
+			return fmt.Errorf("%s: invalid %s translations: %s", path, format, err.Error())
+		}
+
+		if _, ok := translations[locale]; !ok {
+			translations[locale] = translationCatalog{}
+		}
+
+		for key, message := range catalog {
+			if _, ok := translations[locale][key]; ok {
+				return fmt.Errorf("%s: translation %q is already defined by another file", path, key)
+			}
+			translations[locale][key] = message
+		}
+
+		return nil
+	}
+
+	var err error
+	if tm.fileSystem != nil {
+		err = fsWalk.WalkDir(tm.fileSystem, tm.translationsDirectory, walk)
+	} else {
+		err = filepath.WalkDir(tm.translationsDirectory, walk)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	tm.translations = translations
+
+	return nil
+}
+
+// Determines the format of a catalog from its extension (empty if it is not a catalog)
+func translationFormat(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+		case ".json":
+			return "json"
+		case ".yaml", ".yml":
+			return "yaml"
+		case ".po":
+			return "po"
+	}
+
+	return ""
+}
+
+// Parses the contents of a catalog. JSON and YAML catalogs may nest keys (`{"nav": {"home": "Home"}}` defines
+// "nav.home") and give plural messages as a map of CLDR categories (`{"one": "...", "other": "..."}`)
+func parseTranslationFile(format string, locale string, buffer []byte) (translationCatalog, error) {
+	if format == "po" {
+		return parsePoFile(locale, buffer)
+	}
+
+	var value any
+	err := decodeDocument(format, buffer, &value)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, ok := value.(map[string]any); !ok {
+		return nil, fmt.Errorf("catalogs must contain an object of messages")
+	}
+
+	catalog := translationCatalog{}
+	err = addTranslations(catalog, "", value)
+	if err != nil {
+		return nil, err
+	}
+
+	return catalog, nil
+}
+
+// Adds a decoded JSON / YAML value to a catalog, flattening nested keys. Empty (untranslated) messages are skipped
+func addTranslations(catalog translationCatalog, key string, value any) error {
+	switch typed := value.(type) {
+		case nil:
+			return nil
+		case map[string]any:
+			if plurals, ok := translationPlurals(typed); ok {
+				if len(plurals["other"]) > 0 {
+					catalog[key] = translationMessage{text: plurals["other"], plurals: plurals}
+				}
+				return nil
+			}
+
+			for name, nested := range typed {
+				if len(key) == 0 && name == ObsoleteMessagesKey {
+					continue
+				}
+
+				if len(key) > 0 {
+					name = key + "." + name
+				}
+
+				err := addTranslations(catalog, name, nested)
+				if err != nil {
+					return err
+				}
+			}
+		case []any:
+			return fmt.Errorf("translation %q must be a string or an object, not a list", key)
+		case string:
+			if len(typed) > 0 {
+				catalog[key] = translationMessage{text: typed}
+			}
+		default:
+			catalog[key] = translationMessage{text: fmt.Sprint(typed)}
+	}
+
+	return nil
+}
+
+// Checks whether a map is a plural message (only CLDR categories as keys, including "other")
+func translationPlurals(values map[string]any) (map[string]string, bool) {
+	if _, ok := values["other"]; !ok {
+		return nil, false
+	}
+
+	plurals := map[string]string{}
+	for category, value := range values {
+		text, ok := value.(string)
+		if !ok || !slices.Contains(pluralCategories, category) {
+			return nil, false
+		}
+		if len(text) > 0 {
+			plurals[category] = text
+		}
+	}
+
+	return plurals, true
+}
+
+// A single entry of a .po file
+type poEntry struct {
+	context	string
+	id		string
+	plural	string
+	forms	map[int]string
+	fuzzy	bool
+}
+
+// Parses a gettext .po file. Fuzzy and untranslated entries are skipped, entries with a `msgctxt` are keyed as
+// "context|msgid" and plural forms are matched to CLDR categories using the file's `Plural-Forms` header
+func parsePoFile(locale string, buffer []byte) (translationCatalog, error) {
+	entries	:= []*poEntry{}
+	entry	:= &poEntry{forms: map[int]string{}}
+	field	:= ""
+	index	:= 0
+
+	flush := func() {
+		if len(field) > 0 {
+			entries = append(entries, entry)
+		}
+		entry	= &poEntry{forms: map[int]string{}}
+		field	= ""
+	}
+
+	for number, line := range strings.Split(string(buffer), "\n") {
+		line = strings.TrimSpace(line)
+
+		if len(line) == 0 {
+			flush()
+			continue
+		}
+
+		if strings.HasPrefix(line, "#") {
+			if strings.HasPrefix(field, "msgstr") {
+				flush()
+			}
+			if strings.HasPrefix(line, "#,") && strings.Contains(line, "fuzzy") {
+				entry.fuzzy = true
+			}
+			continue
+		}
+
+		keyword, quoted := "", line
+		if !strings.HasPrefix(line, `"`) {
+			keyword, quoted, _ = strings.Cut(line, " ")
+		}
+
+		value, err := strconv.Unquote(strings.TrimSpace(quoted))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid string %s", number + 1, quoted)
+		}
+
+		if len(keyword) == 0 {
+			if len(field) == 0 {
+				return nil, fmt.Errorf("line %d: string without a keyword", number + 1)
+			}
+		} else {
+			if (keyword == "msgctxt" || keyword == "msgid") && strings.HasPrefix(field, "msgstr") {
+				flush()
+			}
+
+			field, index = keyword, 0
+			if strings.HasPrefix(keyword, "msgstr[") && strings.HasSuffix(keyword, "]") {
+				field = "msgstr"
+				index, err = strconv.Atoi(keyword[7:len(keyword) - 1])
+				if err != nil {
+					return nil, fmt.Errorf("line %d: invalid keyword %s", number + 1, keyword)
+				}
+			}
+		}
+
+		switch field {
+			case "msgctxt":
+				entry.context += value
+			case "msgid":
+				entry.id += value
+			case "msgid_plural":
+				entry.plural += value
+			case "msgstr":
+				entry.forms[index] += value
+			default:
+				return nil, fmt.Errorf("line %d: unknown keyword %s", number + 1, keyword)
+		}
+	}
+	flush()
+
+	expression, _ := parsePluralExpression("n != 1")
+	catalog := translationCatalog{}
+
+	for _, entry := range entries {
+		if len(entry.id) == 0 && len(entry.context) == 0 {
+			if match := regexps["findPoPluralForms"].FindStringSubmatch(entry.forms[0]); match != nil {
+				parsed, err := parsePluralExpression(match[1])
+				if err != nil {
+					return nil, err
+				}
+				expression = parsed
+			}
+			continue
+		}
+
+		key := entry.id
+		if len(entry.context) > 0 {
+			key = entry.context + "|" + entry.id
+		}
+
+		if entry.fuzzy || len(entry.forms[0]) == 0 {
+			continue
+		}
+
+		message := translationMessage{text: entry.forms[0]}
+		if len(entry.plural) > 0 {
+			message.plurals = poPlurals(locale, entry.forms, expression)
+		}
+		catalog[key] = message
+	}
+
+	return catalog, nil
+}
+
+// Matches the numbered forms of a .po plural entry to CLDR categories by comparing the `Plural-Forms` expression
+// with the locale's CLDR rules. The last form is also used as "other" if no number chose it
+func poPlurals(locale string, forms map[int]string, expression pluralExpression) map[string]string {
+	plurals := map[string]string{}
+
+	for n := int64(0); n <= 1000; n++ {
+		form, ok := forms[int(expression(n))]
+		if !ok || len(form) == 0 {
+			continue
+		}
+
+		category := pluralCategory(locale, pluralOperands{n: float64(n), i: n})
+		if _, ok := plurals[category]; !ok {
+			plurals[category] = form
+		}
+	}
+
+	if _, ok := plurals["other"]; !ok {
+		last := -1
+		for index, form := range forms {
+			if index > last && len(form) > 0 {
+				last = index
+			}
+		}
+		plurals["other"] = forms[last]
+	}
+
+	return plurals
+}
+
+// Chooses the text of a message for a CLDR plural category
+func (m translationMessage) plural(category string) string {
+	if text, ok := m.plurals[category]; ok {
+		return text
+	}
+	if text, ok := m.plurals["other"]; ok {
+		return text
+	}
+
+	return m.text
+}
+
+// Lists every locale that templates can be rendered in: those with a catalog and those added by `AddLocale()`
+func (tm *TemplateManager) availableLocales() []string {
+	locales := []string{}
+	for locale := range tm.translations {
+		locales = append(locales, locale)
+	}
+	for _, locale := range tm.locales {
+		if locale = normaliseLocale(locale); !slices.Contains(locales, locale) {
+			locales = append(locales, locale)
+		}
+	}
+	sort.Strings(locales)
+
+	return locales
+}
+
+// Reports whether templates can be rendered in a (normalised) locale
+func (tm *TemplateManager) hasLocale(locale string) bool {
+	if _, ok := tm.translations[locale]; ok {
+		return true
+	}
+
+	for _, added := range tm.locales {
+		if normaliseLocale(added) == locale {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Finds the available locale to use for a locale: itself or, failing that, its language ("fr-ca" falls back to
+// "fr"). Returns "" if there is neither
+func (tm *TemplateManager) matchLocale(locale string) string {
+	locale = normaliseLocale(locale)
+	if tm.hasLocale(locale) {
+		return locale
+	}
+
+	language, _, _ := strings.Cut(locale, "-")
+	if tm.hasLocale(language) {
+		return language
+	}
+
+	return ""
+}
+
+// Finds a message for a locale, returning it along with the locale that it was found in. Messages missing from the
+// locale are taken from the default locale (or are the key itself) and are not `found`
+func (tm *TemplateManager) findTranslation(locale string, key string) (translationMessage, string, bool) {
+	if match := tm.matchLocale(locale); len(match) > 0 {
+		if message, ok := tm.translations[match][key]; ok {
+			return message, match, true
+		}
+	}
+
+	if match := tm.matchLocale(tm.defaultLocale); len(match) > 0 {
+		if message, ok := tm.translations[match][key]; ok {
+			return message, match, false
+		}
+	}
+
+	return translationMessage{text: key}, locale, false
+}
+
+// Translates a message into a locale (the default locale if it is empty), choosing its plural form from `count` if
+// it is `plural` and replacing its `{Name}` placeholders
+func (tm *TemplateManager) translate(locale string, key string, plural bool, count any, args []any) (string, error) {
+	if len(locale) == 0 {
+		locale = tm.defaultLocale
+	}
+
+	placeholders, err := translationPlaceholders(args)
+	if err != nil {
+		return "", fmt.Errorf("translation %q: %s", key, err.Error())
+	}
+
+	message, messageLocale, found := tm.findTranslation(locale, key)
+	if !found {
+		err = logWarning(fmt.Sprintf("translation %q is missing for locale %s", key, locale))
+		if err != nil {
+			return "", err
+		}
+	}
+
+	text := message.text
+	if plural {
+		operands, err := newPluralOperands(count)
+		if err != nil {
+			return "", fmt.Errorf("translation %q: %s", key, err.Error())
+		}
+
+		text = message.plural(pluralCategory(messageLocale, operands))
+		if _, ok := placeholders["Count"]; !ok {
+			placeholders["Count"] = count
+		}
+	}
+
+	return regexps["findTranslationPlaceholder"].ReplaceAllStringFunc(text, func(placeholder string) string {
+		if value, ok := placeholders[placeholder[1:len(placeholder) - 1]]; ok {
+			return fmt.Sprint(value)
+		}
+		return placeholder
+	}), nil
+}
+
+// Reads the placeholder values given to `t` / `tn`: either a single map or pairs of names and values
+func translationPlaceholders(args []any) (map[string]any, error) {
+	placeholders := map[string]any{}
+
+	if len(args) == 1 {
+		value := reflect.ValueOf(args[0])
+		if value.Kind() != reflect.Map || value.Type().Key().Kind() != reflect.String {
+			return nil, fmt.Errorf("placeholders must be a map or pairs of names and values")
+		}
+
+		iter := value.MapRange()
+		for iter.Next() {
+			placeholders[iter.Key().String()] = iter.Value().Interface()
+		}
+
+		return placeholders, nil
+	}
+
+	if len(args) % 2 != 0 {
+		return nil, fmt.Errorf("placeholders must be pairs of names and values")
+	}
+
+	for i := 0; i < len(args); i += 2 {
+		name, ok := args[i].(string)
+		if !ok {
+			return nil, fmt.Errorf("placeholder names must be strings, not %T", args[i])
+		}
+		placeholders[name] = args[i + 1]
+	}
+
+	return placeholders, nil
+}
+
+// Replaces `{{ trans }}Hello {Name}{{ end }}` blocks with the equivalent `t` call. Any arguments given to `trans` are
+// passed on as placeholders
+func (tm *TemplateManager) parseTransBlocks(content string) (string, error) {
+	blocks, err := tm.findTransBlocks(content)
+	if err != nil {
+		return "", err
+	}
+
+	parsed, last := "", 0
+	for _, block := range blocks {
+		open	:= strings.TrimSuffix(strings.TrimPrefix(content[block[0]:block[1]], tm.delimiterLeft), tm.delimiterRight)
+		close	:= strings.TrimSuffix(strings.TrimPrefix(content[block[2]:block[3]], tm.delimiterLeft), tm.delimiterRight)
+
+		action := tm.delimiterLeft
+		if strings.HasPrefix(open, "- ") {
+			action += "-"
+		}
+
+		action += " t " + strconv.Quote(transKey(content[block[1]:block[2]]))
+		args := strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(strings.TrimPrefix(open, "- ")), " -"))
+		if args = strings.TrimSpace(strings.TrimPrefix(args, "trans")); len(args) > 0 {
+			action += " " + args
+		}
+
+		if strings.HasSuffix(close, " -") {
+			action += " -"
+		}
+
+		parsed	+= content[last:block[0]] + action + " " + tm.delimiterRight
+		last	= block[3]
+	}
+
+	return parsed + content[last:], nil
+}
+
+/*
+Finds the `{{ trans }} ... {{ end }}` blocks of a template, returning the positions of each `trans` action and its
+`end`. The content of a block becomes its message key, so any action nested within it (e.g. `{{ if }}`) is an error
+*/
+func (tm *TemplateManager) findTransBlocks(content string) ([][4]int, error) {
+	blocks	:= [][4]int{}
+	actions	:= findTemplateActions(content, tm.delimiterLeft, tm.delimiterRight)
+	for i := 0; i < len(actions); i++ {
+		open := content[actions[i][0]:actions[i][1]]
+		if tm.actionKeyword(open) != "trans" {
+			continue
+		}
+
+		if i + 1 == len(actions) {
+			return nil, fmt.Errorf("%s has no matching end", open)
+		}
+
+		close := content[actions[i + 1][0]:actions[i + 1][1]]
+		if tm.actionKeyword(close) != "end" {
+			return nil, fmt.Errorf("%s can only contain text and {Placeholder}s, not %s", open, close)
+		}
+
+		blocks = append(blocks, [4]int{actions[i][0], actions[i][1], actions[i + 1][0], actions[i + 1][1]})
+		i++
+	}
+
+	return blocks, nil
+}
+
+// Creates the key of a `trans` block from its content (trimmed, with all whitespace collapsed to single spaces)
+func transKey(content string) string {
+	return strings.Join(strings.Fields(content), " ")
+}
+
+// The built-in `t` function, which returns the key. Templates use the version from `translationFunctions()`
+func translateFn(key string, args ...any) (string, error) {
+	return key, nil
+}
+
+// The built-in `tn` function, which returns the key. Templates use the version from `translationFunctions()`
+func translatePluralFn(key string, count any, args ...any) (string, error) {
+	return key, nil
+}
+
+// Creates versions of the `t` and `tn` template functions (under whatever names they are registered with) for a
+// locale ("" for the default locale)
+func (tm *TemplateManager) translationFunctions(locale string) map[string]any {
+	functions := map[string]any{}
+	for _, name := range tm.builtinFunctionNames(translateFn) {
+		functions[name] = func(key string, args ...any) (string, error) {
+			return tm.translate(locale, key, false, nil, args)
+		}
+	}
+	for _, name := range tm.builtinFunctionNames(translatePluralFn) {
+		functions[name] = func(key string, count any, args ...any) (string, error) {
+			return tm.translate(locale, key, true, count, args)
+		}
+	}
+
+	return functions
+}
+
+// Finds the names that a built-in function is registered with. Functions which were removed or replaced by custom
+// functions are not found, so their locale dependent versions are never added
+func (tm *TemplateManager) builtinFunctionNames(function any) []string {
+	pointer := reflect.ValueOf(function).Pointer()
+
+	names := []string{}
+	for name, registered := range tm.functions {
+		value := reflect.ValueOf(registered)
+		if value.Kind() == reflect.Func && value.Pointer() == pointer {
+			names = append(names, name)
+		}
+	}
+
+	return names
+}
+
+// Creates the template functions which depend upon a locale ("" for the default locale): translation, number
+// formatting and dates
+func (tm *TemplateManager) localeFunctions(locale string) map[string]any {
+	functions := tm.translationFunctions(locale)
+	for name, function := range tm.numberFunctions(locale) {
+		functions[name] = function
+	}
+	for name, function := range tm.dateFunctions(locale) {
+		functions[name] = function
+	}
+
+	return functions
+}
+
+// Keeps an unexecuted copy of the `name` template from which its localised copies are made when it is first rendered
+// in each locale (`html/template` templates cannot be copied once they have been executed)
+func (tm *TemplateManager) localiseTemplate(name string) error {
+	tm.localeMutex.Lock()
+	defer tm.localeMutex.Unlock()
+
+	delete(tm.localeSources, name)
+	delete(tm.localeTemplates, name)
+
+	for _, locale := range tm.availableLocales() {
+		if locale != normaliseLocale(tm.defaultLocale) {
+			source, err := tm.templates[name].Clone()
+			if err != nil {
+				return err
+			}
+
+			tm.localeSources[name] = source
+			break
+		}
+	}
+
+	return nil
+}
+
+// Finds the copy of the `name` template whose locale dependent functions use `locale`, creating it the first time
+// that it is needed. Returns nil for the default locale (which uses the template itself)
+func (tm *TemplateManager) localisedTemplate(name string, locale string) *Template {
+	if len(locale) == 0 || locale == normaliseLocale(tm.defaultLocale) {
+		return nil
+	}
+
+	tm.localeMutex.Lock()
+	defer tm.localeMutex.Unlock()
+
+	if tmpl, ok := tm.localeTemplates[name][locale]; ok {
+		return tmpl
+	}
+
+	source, ok := tm.localeSources[name]
+	if !ok {
+		return nil
+	}
+
+	tmpl, err := source.Clone()
+	if err != nil {
+		logError("template %s could not be localised for %s: %s", name, locale, err.Error())
+		return nil
+	}
+
+	tmpl.Funcs(map[string]any{"render": templateRenderFunction(tmpl)})
+	tmpl.Funcs(tm.localeFunctions(locale))
+
+	if _, ok := tm.localeTemplates[name]; !ok {
+		tm.localeTemplates[name] = map[string]*Template{}
+	}
+	tm.localeTemplates[name][locale] = tmpl
+
+	return tmpl
+}
@@ -0,0 +1,504 @@
+package templateManager
+
+import (
+	"bytes"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestAAI18nSetup(tester  *testing.T) {
+	testsShowDetails	= true
+	testsShowSuccessful = false
+	consoleErrors		= false
+	consoleWarnings		= false
+	haltOnErrors		= false
+	haltOnWarnings		= false
+
+	testFormatTitle("i18n")
+}
+
+func TestPluralCategory(tester *testing.T) {
+	tests := []struct{ locale string; count any; expected string }{
+		{"en", 1, "one"},
+		{"en", 0, "other"},
+		{"en", "1.0", "other"},
+		{"en_GB", 2, "other"},
+		{"fr", 0, "one"},
+		{"fr", 1.5, "one"},
+		{"fr", 1000000, "many"},
+		{"ru", 1, "one"},
+		{"ru", 21, "one"},
+		{"ru", 11, "many"},
+		{"ru", 3, "few"},
+		{"ru", 5, "many"},
+		{"ru", 1.5, "other"},
+		{"pl", 22, "few"},
+		{"pl", 12, "many"},
+		{"cs", 3, "few"},
+		{"ar", 0, "zero"},
+		{"ar", 2, "two"},
+		{"ar", 105, "few"},
+		{"ar", 111, "many"},
+		{"ja", 1, "other"},
+		{"pt-PT", 0, "other"},
+		{"pt-BR", 0, "one"},
+		{"xx", 1, "one"},
+	}
+
+	passed, failed := 0, 0
+	for _, test := range tests {
+		operands, err := newPluralOperands(test.count)
+		result := pluralCategory(test.locale, operands)
+		if err == nil && result == test.expected {
+			passed++
+		} else {
+			tester.Errorf("\033[31mFAIL: \033[36mpluralCategory(%s, %v)\033[0m:\n\t\033[31mProduced: \033[33m%s (%v)\033[0m\n\t\033[31mExpected: \033[33m%s\033[0m", test.locale, test.count, result, err, test.expected)
+			failed++
+		}
+	}
+
+	testFormatPassFail("pluralCategory", passed, failed)
+}
+
+func TestParsePluralExpression(tester *testing.T) {
+	tests := map[string][]int64{
+		"n != 1":	{1, 0, 1, 1, 1, 1},
+		"n>1":		{0, 0, 1, 1, 1, 1},
+		"0":		{0, 0, 0, 0, 0, 0},
+		"(n%10==1 && n%100!=11 ? 0 : n%10>=2 && n%10<=4 && (n%100<10 || n%100>=20) ? 1 : 2)": {2, 0, 1, 2, 2, 0},
+	}
+	numbers := []int64{0, 1, 2, 5, 11, 21}
+
+	passed, failed := 0, 0
+	for expression, expected := range tests {
+		parsed, err := parsePluralExpression(expression)
+		results := []int64{}
+		if err == nil {
+			for _, n := range numbers {
+				results = append(results, parsed(n))
+			}
+		}
+
+		if reflect.DeepEqual(results, expected) {
+			passed++
+		} else {
+			tester.Errorf("\033[31mFAIL: \033[36mparsePluralExpression(%s)\033[0m:\n\t\033[31mProduced: \033[33m%v (%v)\033[0m\n\t\033[31mExpected: \033[33m%v\033[0m", expression, results, err, expected)
+			failed++
+		}
+	}
+
+	for _, expression := range []string{"n ==", "(n", "n ? 1", "x"} {
+		if _, err := parsePluralExpression(expression); err != nil {
+			passed++
+		} else {
+			tester.Errorf("\033[31mFAIL: \033[36mparsePluralExpression(%s)\033[0m: expected an error", expression)
+			failed++
+		}
+	}
+
+	testFormatPassFail("parsePluralExpression", passed, failed)
+}
+
+func TestTranslate(tester *testing.T) {
+	files := fstest.MapFS{
+		"templates/index.html": {Data: []byte(`{{ t "greeting" "Name" .Name }} {{ tn "items" .Count }} {{ t "nav.home" }}{{ trans }} Bye {{ end }}`)},
+		"translations/en.json": {Data: []byte(`{"greeting": "Hello {Name}", "items": {"one": "{Count} item", "other": "{Count} items"}, "nav": {"home": "Home"}, "Bye": "", "untranslated": {"one": "", "other": ""}}`)},
+		"translations/fr/main.yaml": {Data: []byte("greeting: Bonjour {Name}\nitems:\n  one: \"{Count} article\"\n  other: \"{Count} articles\"\n")},
+		"translations/ru.po": {Data: []byte(`msgid ""
+msgstr ""
+"Plural-Forms: nplurals=3; plural=(n%10==1 && n%100!=11 ? 0 : n%10>=2 && n%10<=4 && (n%100<10 || n%100>=20) ? 1 : 2);\n"
+
+msgid "greeting"
+msgstr "Привет, "
+"{Name}"
+
+msgid "items"
+msgid_plural "items"
+msgstr[0] "{Count} предмет"
+msgstr[1] "{Count} предмета"
+msgstr[2] "{Count} предметов"
+
+#, fuzzy
+msgid "nav.home"
+msgstr "Дом"
+
+msgid "Bye"
+msgstr "Пока"
+`)},
+	}
+
+	tm := Init("templates", ".html").TranslationsDirectory("translations")
+	tm.fileSystem = http.FS(files)
+
+	tests := []struct{ data Params; expected string }{
+		{Params{"Name": "Ann", "Count": 1}, "Hello Ann 1 item HomeBye"},
+		{Params{"Name": "Ann", "Count": 0, "Locale": "fr-CA"}, "Bonjour Ann 0 article HomeBye"},
+		{Params{"Name": "Ann", "Count": 2, "Locale": "fr"}, "Bonjour Ann 2 articles HomeBye"},
+		{Params{"Name": "Ann", "Count": 22, "Locale": "ru"}, "Привет, Ann 22 предмета HomeПока"},
+		{Params{"Name": "Ann", "Count": 25, "Locale": "ru_RU"}, "Привет, Ann 25 предметов HomeПока"},
+		{Params{"Name": "Ann", "Count": 1, "Locale": "de"}, "Hello Ann 1 item HomeBye"},
+	}
+
+	passed, failed := 0, 0
+	for _, test := range tests {
+		buf := &bytes.Buffer{}
+		err := tm.Render("index.html", test.data, buf)
+		if err == nil && buf.String() == test.expected {
+			passed++
+		} else {
+			tester.Errorf("\033[31mFAIL: \033[36mt / tn(%v)\033[0m:\n\t\033[31mProduced: \033[33m%q (%v)\033[0m\n\t\033[31mExpected: \033[33m%q\033[0m", test.data, buf.String(), err, test.expected)
+			failed++
+		}
+	}
+
+	testFormatPassFail("t / tn", passed, failed)
+}
+
+func TestTransBlocks(tester *testing.T) {
+	files := fstest.MapFS{
+		"templates/index.html":		{Data: []byte("<p>\n\t{{- trans \"Name\" .Name -}}\n\t\tHello,\n\t\t{Name}!\n\t{{- end }}</p>{{ if .Name }}{{ trans }}Bye{{ end }}{{ end }}")},
+		"templates/if.html":		{Data: []byte(`{{ trans }}Hello{{ if .Name }} {Name}{{ end }}!{{ end }}`)},
+		"templates/range.html":		{Data: []byte(`{{ trans }}{{ range .Names }}Hello {{ . }}{{ end }}{{ end }}`)},
+		"translations/fr.json":		{Data: []byte(`{"Hello, {Name}!": "Bonjour, {Name} !", "Bye": "Au revoir"}`)},
+	}
+
+	init := func(names ...string) *TemplateManager {
+		only := fstest.MapFS{"translations/fr.json": files["translations/fr.json"]}
+		for _, name := range names {
+			only["templates/" + name] = files["templates/" + name]
+		}
+
+		tm := Init("templates", ".html").TranslationsDirectory("translations")
+		tm.fileSystem = http.FS(only)
+		return tm
+	}
+
+	errorMessage := func(err error) string {
+		if err == nil {
+			return ""
+		}
+		return err.Error()
+	}
+
+	buf := &bytes.Buffer{}
+	err := init("index.html").Render("index.html", Params{"Name": "Ann", "Locale": "fr"}, buf)
+
+	messages, _ := init("index.html").ExtractMessages()
+	keys := []string{}
+	for _, message := range messages {
+		keys = append(keys, message.Key)
+	}
+
+	_, ifExtractError	:= init("if.html").ExtractMessages()
+	_, rangeExtractError	:= init("range.html").ExtractMessages()
+
+	tests := []struct { inputs []any; result any; expected any }{
+		{[]any{"index.html", "render"}, []any{buf.String(), err}, []any{"<p>Bonjour, Ann !</p>Au revoir", nil}},
+		{[]any{"index.html", "extract"}, keys, []string{"Bye", "Hello, {Name}!"}},
+		{[]any{"if.html", "parse"}, errorMessage(init("if.html").Parse()), "if.html: {{ trans }} can only contain text and {Placeholder}s, not {{ if .Name }}"},
+		{[]any{"if.html", "extract"}, errorMessage(ifExtractError), "if.html: {{ trans }} can only contain text and {Placeholder}s, not {{ if .Name }}"},
+		{[]any{"range.html", "parse"}, errorMessage(init("range.html").Parse()), "range.html: {{ trans }} can only contain text and {Placeholder}s, not {{ range .Names }}"},
+		{[]any{"range.html", "extract"}, errorMessage(rangeExtractError), "range.html: {{ trans }} can only contain text and {Placeholder}s, not {{ range .Names }}"},
+	}
+
+	testRunTests("transBlocks", tests, tester)
+}
+
+func TestLocaleFunctions(tester *testing.T) {
+	files := fstest.MapFS{
+		"templates/index.html":		{Data: []byte(`{{ t "greeting" }} {{ number 1234.5 }}`)},
+		"translations/en.json":		{Data: []byte(`{"greeting": "Hello"}`)},
+		"translations/fr.json":		{Data: []byte(`{"greeting": "Bonjour"}`)},
+		"translations/de.json":		{Data: []byte(`{"greeting": "Hallo"}`)},
+	}
+
+	render := func(tm *TemplateManager, locale string) string {
+		buf := &bytes.Buffer{}
+		err := tm.Render("index.html", Params{"Locale": locale}, buf)
+		if err != nil {
+			return err.Error()
+		}
+		return buf.String()
+	}
+
+	init := func(files fstest.MapFS) *TemplateManager {
+		tm := Init("templates", ".html").TranslationsDirectory("translations")
+		tm.fileSystem = http.FS(files)
+		return tm
+	}
+
+	custom := init(files).AddFunction("number", func(value any) string { return "custom" })
+
+	removedError := ""
+	if err := init(files).RemoveFunction("t").Parse(); err != nil {
+		removedError = err.Error()
+	}
+
+	renamed := init(fstest.MapFS{
+		"templates/index.html":		{Data: []byte(`{{ translate "greeting" }}`)},
+		"translations/en.json":		files["translations/en.json"],
+		"translations/de.json":		files["translations/de.json"],
+	}).RenameFunction("t", "translate")
+
+	lazy := init(files)
+	lazy.Parse()
+	copies := []int{len(lazy.localeTemplates["index.html"])}
+	results := []string{render(lazy, ""), render(lazy, "fr"), render(lazy, "fr-CA")}
+	copies = append(copies, len(lazy.localeTemplates["index.html"]))
+
+	tests := []struct { inputs []any; result any; expected any }{
+		{[]any{"custom number", "en"}, render(custom, "en"), "Hello custom"},
+		{[]any{"custom number", "fr"}, render(custom, "fr"), "Bonjour custom"},
+		{[]any{"renamed t", "en"}, render(renamed, "en"), "Hello"},
+		{[]any{"renamed t", "de"}, render(renamed, "de"), "Hallo"},
+		{[]any{"removed t"}, strings.Contains(removedError, `function "t" not defined`), true},
+		{[]any{"lazy copies", "renders"}, results, []string{"Hello 1,234.5", "Bonjour 1\u202f234,5", "Bonjour 1\u202f234,5"}},
+		{[]any{"lazy copies", "count"}, copies, []int{0, 1}},
+	}
+
+	testRunTests("localeFunctions", tests, tester)
+}
+
+func TestExtractMessages(tester *testing.T) {
+	files := fstest.MapFS{
+		"templates/layouts/main.html": {Data: []byte("{{ block \"content\" . }}{{ end }}\n{{ t `footer` }}")},
+		"templates/components/Badge.html": {Data: []byte(`<span>{{ tn "badge.count" .Count }}</span>`)},
+		"templates/index.html": {Data: []byte(`{{ extends "layouts/main.html" }}
+{{ define "content" }}{{ t "greeting" "Name" .Name }} {{ printf "%s" (t "nav.home") }}
+{{- trans "Name" .Name }}
+	Welcome back,
+	{Name}!
+{{ end }}{{ t "greeting" }}{{ end }}`)},
+	}
+
+	tm := Init("templates", ".html")
+	tm.fileSystem = http.FS(files)
+
+	messages, err := tm.ExtractMessages()
+	if err != nil {
+		tester.Fatalf("\033[31mFAIL: \033[36mExtractMessages\033[0m: %s", err.Error())
+	}
+
+	expected := []ExtractedMessage{
+		{"Welcome back, {Name}!", false, []string{"index.html:3"}},
+		{"badge.count", true, []string{"components/Badge.html:1"}},
+		{"footer", false, []string{"layouts/main.html:2"}},
+		{"greeting", false, []string{"index.html:2", "index.html:6"}},
+		{"nav.home", false, []string{"index.html:2"}},
+	}
+
+	passed, failed := 0, 0
+	if reflect.DeepEqual(messages, expected) {
+		passed++
+	} else {
+		tester.Errorf("\033[31mFAIL: \033[36mExtractMessages\033[0m:\n\t\033[31mProduced: \033[33m%#v\033[0m\n\t\033[31mExpected: \033[33m%#v\033[0m", messages, expected)
+		failed++
+	}
+
+	testFormatPassFail("ExtractMessages", passed, failed)
+}
+
+func TestNumberFunctions(tester *testing.T) {
+	v := reflect.ValueOf
+	tests := []struct{ name string; function func(string, ...reflect.Value) (string, error); locale string; args []reflect.Value; expected string }{
+		{"number", numberFormat, "en", []reflect.Value{v(1234567)}, "1,234,567"},
+		{"number", numberFormat, "en", []reflect.Value{v(uint64(18446744073709551615))}, "18,446,744,073,709,551,615"},
+		{"number", numberFormat, "en", []reflect.Value{v(-1234.5678)}, "-1,234.568"},
+		{"number", numberFormat, "en", []reflect.Value{v(2), v(float32(1234.5))}, "1,234.50"},
+		{"number", numberFormat, "en", []reflect.Value{v(2), v("-0.001")}, "0.00"},
+		{"number", numberFormat, "en", []reflect.Value{v(true)}, "1"},
+		{"number", numberFormat, "de-AT", []reflect.Value{v(1234567.891)}, "1.234.567,891"},
+		{"number", numberFormat, "fr", []reflect.Value{v(1234.5)}, "1\u202f234,5"},
+		{"number", numberFormat, "es", []reflect.Value{v(1234)}, "1234"},
+		{"number", numberFormat, "es", []reflect.Value{v(12345)}, "12.345"},
+		{"number", numberFormat, "en_IN", []reflect.Value{v(12345678)}, "1,23,45,678"},
+		{"percent", numberPercent, "en", []reflect.Value{v(0.256)}, "26%"},
+		{"percent", numberPercent, "de", []reflect.Value{v(1), v(0.256)}, "25,6\u00a0%"},
+		{"compact", numberCompact, "en", []reflect.Value{v(1234)}, "1.2K"},
+		{"compact", numberCompact, "en", []reflect.Value{v(999999)}, "1M"},
+		{"compact", numberCompact, "en", []reflect.Value{v(-2500000000)}, "-2.5B"},
+		{"compact", numberCompact, "en", []reflect.Value{v(12.345)}, "12.3"},
+		{"compact", numberCompact, "de", []reflect.Value{v(1234)}, "1.234"},
+		{"compact", numberCompact, "ja", []reflect.Value{v(123456)}, "12.3万"},
+		{"filesize", numberFilesize, "en", []reflect.Value{v(512)}, "512 B"},
+		{"filesize", numberFilesize, "en", []reflect.Value{v(3400000)}, "3.4 MB"},
+		{"filesize", numberFilesize, "en", []reflect.Value{v(999999)}, "1 MB"},
+		{"filesize", numberFilesize, "fr", []reflect.Value{v(2), v(1234567)}, "1,23 Mo"},
+	}
+
+	passed, failed := 0, 0
+	for _, test := range tests {
+		result, err := test.function(test.locale, test.args...)
+		if err == nil && result == test.expected {
+			passed++
+		} else {
+			tester.Errorf("\033[31mFAIL: \033[36m%s(%s, %v)\033[0m:\n\t\033[31mProduced: \033[33m%q (%v)\033[0m\n\t\033[31mExpected: \033[33m%q\033[0m", test.name, test.locale, test.args, result, err, test.expected)
+			failed++
+		}
+	}
+
+	currencies := []struct{ locale string; code string; args []reflect.Value; expected string }{
+		{"en", "GBP", []reflect.Value{v(1234.5)}, "£1,234.50"},
+		{"en", "usd", []reflect.Value{v(-5)}, "-$5.00"},
+		{"en", "JPY", []reflect.Value{v(1234.5)}, "¥1,234"},
+		{"en", "CHF", []reflect.Value{v(0), v(10)}, "CHF\u00a010"},
+		{"fr", "EUR", []reflect.Value{v(1234.5)}, "1\u202f234,50\u00a0€"},
+		{"de-CH", "CHF", []reflect.Value{v(1234.5)}, "CHF\u00a01’234.50"},
+	}
+	for _, test := range currencies {
+		result, err := numberCurrency(test.locale, v(test.code), test.args...)
+		if err == nil && result == test.expected {
+			passed++
+		} else {
+			tester.Errorf("\033[31mFAIL: \033[36mcurrency(%s, %s, %v)\033[0m:\n\t\033[31mProduced: \033[33m%q (%v)\033[0m\n\t\033[31mExpected: \033[33m%q\033[0m", test.locale, test.code, test.args, result, err, test.expected)
+			failed++
+		}
+	}
+
+	files := fstest.MapFS{
+		"templates/index.html": {Data: []byte(`{{ number .Total }} {{ currency "EUR" .Total }} {{ percent 0.5 }}`)},
+	}
+
+	tm := Init("templates", ".html").AddLocale("de")
+	tm.fileSystem = http.FS(files)
+
+	renders := []struct{ data Params; expected string }{
+		{Params{"Total": 1234.5}, "1,234.5 €1,234.50 50%"},
+		{Params{"Total": 1234.5, "Locale": "de-DE"}, "1.234,5 1.234,50\u00a0€ 50\u00a0%"},
+	}
+	for _, test := range renders {
+		buf := &bytes.Buffer{}
+		err := tm.Render("index.html", test.data, buf)
+		if err == nil && buf.String() == test.expected {
+			passed++
+		} else {
+			tester.Errorf("\033[31mFAIL: \033[36mnumber functions(%v)\033[0m:\n\t\033[31mProduced: \033[33m%q (%v)\033[0m\n\t\033[31mExpected: \033[33m%q\033[0m", test.data, buf.String(), err, test.expected)
+			failed++
+		}
+	}
+
+	testFormatPassFail("number / currency / percent / compact / filesize", passed, failed)
+}
+
+func TestDateLocales(tester *testing.T) {
+	testTime := time.Date(2020, 2, 1, 14, 5, 0, 0, time.UTC)
+	fr, ru := localeArgument("fr"), localeArgument("ru_RU")
+	fn := func(d string, _ error) string { return d }
+
+	tests := []struct{ name string; result string; expected string }{
+		{"date(l jS F Y)", fn(date("l jS F Y", testTime)), "Saturday 1st February 2020"},
+		{"date(fr, l jS F Y)", fn(date(fr, "l jS F Y", testTime)), "samedi 1er février 2020"},
+		{"date(fr, D j M)", fn(date(fr, "D j M", testTime)), "sam. 1 févr."},
+		{"date(ru, j F Y)", fn(date(ru, "j F Y", testTime)), "1 февраля 2020"},
+		{"date(ru, F Y)", fn(date(ru, "F Y", testTime)), "февраль 2020"},
+		{"date(de-AT)", fn(date(localeArgument("de-AT"), testTime)), "01/02/2020"},
+		{"datetime(sv, g:i a)", fn(datetime(localeArgument("sv"), "g:i a", testTime)), "2:05 em"},
+		{"time(nn, l)", fn(timeFn(localeArgument("nn"), "l", testTime)), "lørdag"},
+		{"formattime(es, Monday 2 January)", fn(formattime(localeArgument("es"), "Monday 2 January 2006", testTime)), "sábado 1 febrero 2020"},
+		{"formattime(xx, l)", fn(formattime(localeArgument("xx"), "l", testTime)), "Saturday"},
+	}
+
+	passed, failed := 0, 0
+	for _, test := range tests {
+		if test.result == test.expected {
+			passed++
+		} else {
+			tester.Errorf("\033[31mFAIL: \033[36m%s\033[0m:\n\t\033[31mProduced: \033[33m%q\033[0m\n\t\033[31mExpected: \033[33m%q\033[0m", test.name, test.result, test.expected)
+			failed++
+		}
+	}
+
+	files := fstest.MapFS{
+		"templates/index.html": {Data: []byte(`{{ date "l j F" .T }} {{ formattime (locale "it") "l" .T }}`)},
+	}
+
+	tm := Init("templates", ".html").DefaultLocale("de").AddLocale("fr")
+	tm.fileSystem = http.FS(files)
+
+	renders := []struct{ data Params; expected string }{
+		{Params{"T": testTime}, "Samstag 1 Februar sabato"},
+		{Params{"T": testTime, "Locale": "fr-BE"}, "samedi 1 février sabato"},
+	}
+	for _, test := range renders {
+		buf := &bytes.Buffer{}
+		err := tm.Render("index.html", test.data, buf)
+		if err == nil && buf.String() == test.expected {
+			passed++
+		} else {
+			tester.Errorf("\033[31mFAIL: \033[36mdate functions(%v)\033[0m:\n\t\033[31mProduced: \033[33m%q (%v)\033[0m\n\t\033[31mExpected: \033[33m%q\033[0m", test.data, buf.String(), err, test.expected)
+			failed++
+		}
+	}
+
+	testFormatPassFail("localised dates", passed, failed)
+}
+
+func TestHumanize(tester *testing.T) {
+	now := time.Date(2020, 2, 10, 12, 0, 0, 0, time.UTC)
+	ago := func(d time.Duration) time.Time { return now.Add(-d) }
+	fn := func(d string, _ error) string { return d }
+
+	tests := []struct{ name string; result string; expected string }{
+		{"naturaltime(5s)", fn(describeRelativeTime(ago(5 * time.Second), now, "second", "en")), "just now"},
+		{"naturaltime(45s)", fn(describeRelativeTime(ago(45 * time.Second), now, "second", "en")), "45 seconds ago"},
+		{"naturaltime(minute, 45s)", fn(describeRelativeTime(ago(45 * time.Second), now, "minute", "en")), "just now"},
+		{"naturaltime(-1m)", fn(describeRelativeTime(ago(-time.Minute), now, "second", "en")), "in 1 minute"},
+		{"naturaltime(3h)", fn(describeRelativeTime(ago(3 * time.Hour), now, "second", "en")), "3 hours ago"},
+		{"naturaltime(day, 3h)", fn(describeRelativeTime(ago(3 * time.Hour), now, "day", "en")), "today"},
+		{"naturaltime(22h)", fn(describeRelativeTime(ago(22 * time.Hour), now, "second", "en")), "yesterday at 14:00"},
+		{"naturaltime(day, -20h)", fn(describeRelativeTime(ago(-20 * time.Hour), now, "day", "en")), "tomorrow"},
+		{"naturaltime(3d)", fn(describeRelativeTime(ago(72 * time.Hour), now, "second", "en")), "3 days ago"},
+		{"naturaltime(-15d)", fn(describeRelativeTime(ago(-15 * 24 * time.Hour), now, "second", "en")), "in 2 weeks"},
+		{"naturaltime(100d)", fn(describeRelativeTime(ago(100 * 24 * time.Hour), now, "second", "en")), "3 months ago"},
+		{"naturaltime(800d)", fn(describeRelativeTime(ago(800 * 24 * time.Hour), now, "second", "en")), "2 years ago"},
+		{"naturaltime(de, 3d)", fn(describeRelativeTime(ago(72 * time.Hour), now, "second", "de")), "vor 3 Tagen"},
+		{"naturaltime(fr, 22h)", fn(describeRelativeTime(ago(22 * time.Hour), now, "second", "fr-CA")), "hier à 14:00"},
+		{"naturaltime(ru, -21m)", fn(describeRelativeTime(ago(-21 * time.Minute), now, "second", "ru")), "через 21 минуту"},
+		{"naturaltime(pl, 5h)", fn(describeRelativeTime(ago(5 * time.Hour), now, "second", "pl")), "5 godzin temu"},
+		{"naturaltime(month)", fn(describeRelativeTime(now, now, "month", "en")), ""},
+		{"humanize(3905)", fn(humanize(3905)), "1 hour, 5 minutes, 5 seconds"},
+		{"humanize(minute, 90m)", fn(humanize("minute", "90m")), "1 hour, 30 minutes"},
+		{"humanize(day, 10m)", fn(humanize("day", 10 * time.Minute)), "0 days"},
+		{"humanize(week, 20d)", fn(humanize("week", 20 * 24 * time.Hour)), "2 weeks"},
+		{"humanize(de, 50h)", fn(humanize(localeArgument("de"), "hour", 50 * time.Hour)), "2 Tage, 2 Stunden"},
+		{"humanize(ru, 5m)", fn(humanize(localeArgument("ru"), 300)), "5 минут"},
+	}
+
+	passed, failed := 0, 0
+	for _, test := range tests {
+		if test.result == test.expected {
+			passed++
+		} else {
+			tester.Errorf("\033[31mFAIL: \033[36m%s\033[0m:\n\t\033[31mProduced: \033[33m%q\033[0m\n\t\033[31mExpected: \033[33m%q\033[0m", test.name, test.result, test.expected)
+			failed++
+		}
+	}
+
+	files := fstest.MapFS{
+		"templates/index.html": {Data: []byte(`{{ naturaltime .T }}, {{ humanize "minute" 7200 }}`)},
+	}
+
+	tm := Init("templates", ".html").DefaultLocale("de").AddLocale("nl")
+	tm.fileSystem = http.FS(files)
+
+	renders := []struct{ data Params; expected string }{
+		{Params{"T": time.Now().Add(-90 * time.Second)}, "vor 1 Minute, 2 Stunden"},
+		{Params{"T": time.Now().Add(-90 * time.Second), "Locale": "nl"}, "1 minuut geleden, 2 uur"},
+	}
+	for _, test := range renders {
+		buf := &bytes.Buffer{}
+		err := tm.Render("index.html", test.data, buf)
+		if err == nil && buf.String() == test.expected {
+			passed++
+		} else {
+			tester.Errorf("\033[31mFAIL: \033[36mhumanize functions(%v)\033[0m:\n\t\033[31mProduced: \033[33m%q (%v)\033[0m\n\t\033[31mExpected: \033[33m%q\033[0m", test.data, buf.String(), err, test.expected)
+			failed++
+		}
+	}
+
+	testFormatPassFail("humanised times", passed, failed)
+}
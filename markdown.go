@@ -0,0 +1,103 @@
+package templateManager
+
+/*
+Functions dedicated to rendering Markdown (CommonMark with tables), for the `markdown` function and `.md` entry templates
+*/
+
+import (
+	"bytes"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/renderer/html"
+)
+
+// The file extensions of Markdown entry templates
+var markdownExtensions = []string{".md", ".markdown"}
+
+// Renders Markdown given to the `markdown` function (raw HTML and dangerous links are removed)
+var markdownRenderer = goldmark.New(goldmark.WithExtensions(extension.Table))
+
+// Renders Markdown entry templates, whose authors are trusted to include raw HTML (and components)
+var markdownTemplateRenderer = goldmark.New(goldmark.WithExtensions(extension.Table), goldmark.WithRendererOptions(html.WithUnsafe()))
+
+// Marks the template actions of a Markdown template while its Markdown is rendered
+var markdownActionMarker = "tmaction" + strings.ReplaceAll(uuid.NewString(), "-", "")
+
+// Finds lines which hold nothing but (marked) template actions, e.g. `{{ if .Visible }}`
+var findMarkdownActionLines = regexp.MustCompile(`(?m)^ {0,3}((?:` + markdownActionMarker + `\d+x[ \t]*)+)$`)
+
+// Finds the HTML comments which kept lines of actions out of paragraphs (escaped within code blocks)
+var findMarkdownActionComments = regexp.MustCompile(`(?:<!--|&lt;!--)((?:` + markdownActionMarker + `\d+x[ \t]*)+)(?:-->|--&gt;)`)
+
+// Checks whether a template file is written in Markdown
+func isMarkdownFile(path string) bool {
+	extension := strings.ToLower(filepath.Ext(path))
+	for _, markdownExtension := range markdownExtensions {
+		if extension == markdownExtension {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Converts Markdown to HTML with the given renderer
+func renderMarkdown(renderer goldmark.Markdown, source string) (string, error) {
+	buf := &bytes.Buffer{}
+	if err := renderer.Convert([]byte(source), buf); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
+
+// Renders the body of a Markdown template to HTML, keeping its template actions (e.g. `{{ .Title }}`) as they are.
+// Lines holding only actions (e.g. `{{ if .Visible }}`) are not wrapped in paragraphs and `{{ define }}` blocks are
+// kept out of the Markdown. If the template extends a layout, the HTML is defined as the layout's "content" block
+func (tm *TemplateManager) parseMarkdownTemplate(content string, extends bool) (string, error) {
+	defines := []string{}
+	for {
+		start, end, _, ok := tm.findTemplateSection(content, "define")
+		if !ok {
+			break
+		}
+
+		defines = append(defines, content[start:end])
+		content = content[:start] + content[end:]
+	}
+
+	actions	:= []string{}
+	marked	:= ""
+	last	:= 0
+	for _, action := range findTemplateActions(content, tm.delimiterLeft, tm.delimiterRight) {
+		marked += content[last:action[0]] + markdownActionMarker + strconv.Itoa(len(actions)) + "x"
+		actions = append(actions, content[action[0]:action[1]])
+		last = action[1]
+	}
+	marked += content[last:]
+
+	// HTML comments start HTML blocks, which Markdown leaves as they are
+	marked = findMarkdownActionLines.ReplaceAllString(marked, "<!--${1}-->")
+
+	rendered, err := renderMarkdown(markdownTemplateRenderer, marked)
+	if err != nil {
+		return "", err
+	}
+
+	rendered = findMarkdownActionComments.ReplaceAllString(rendered, "${1}")
+	for i := len(actions) - 1; i >= 0; i-- {
+		rendered = strings.ReplaceAll(rendered, markdownActionMarker + strconv.Itoa(i) + "x", actions[i])
+	}
+
+	if extends {
+		rendered = tm.delimiterLeft + `- define "content" -` + tm.delimiterRight + rendered + tm.delimiterLeft + `- end -` + tm.delimiterRight
+	}
+
+	return rendered + strings.Join(defines, ""), nil
+}
@@ -0,0 +1,422 @@
+package templateManager
+
+/*
+Helpers for formatting numbers, currencies, percentages, compact numbers and file sizes for a locale (the template functions are in `functions.go`)
+*/
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// The symbols and patterns used to format numbers in a locale. In the patterns "#" is the formatted number and "¤"
+// the currency symbol
+type numberSymbols struct {
+	decimal			string
+	group			string
+	minimumGrouping	int
+	indian			bool
+	currency		string
+	percent			string
+	compact			[]numberCompactUnit
+	bytes			[]string
+}
+
+// A magnitude that compact numbers are abbreviated to (e.g. 1000 is "K")
+type numberCompactUnit struct {
+	size	float64
+	suffix	string
+}
+
+var numberEnglishCompactUnits = []numberCompactUnit{{1e3, "K"}, {1e6, "M"}, {1e9, "B"}, {1e12, "T"}}
+
+var numberEnglishByteUnits = []string{"B", "kB", "MB", "GB", "TB", "PB"}
+
+// The number formats of each supported locale (others use their language's format, or English)
+var numberLocales = map[string]numberSymbols{
+	"en":		{decimal: ".", group: ",", currency: "¤#", percent: "#%", compact: numberEnglishCompactUnits},
+	"en-in":	{decimal: ".", group: ",", indian: true, currency: "¤#", percent: "#%", compact: []numberCompactUnit{{1e3, "K"}, {1e5, "L"}, {1e7, "Cr"}}},
+	"de":		{decimal: ",", group: ".", currency: "#\u00a0¤", percent: "#\u00a0%", compact: []numberCompactUnit{{1e6, "\u00a0Mio."}, {1e9, "\u00a0Mrd."}, {1e12, "\u00a0Bio."}}},
+	"de-ch":	{decimal: ".", group: "’", currency: "¤\u00a0#", percent: "#%", compact: []numberCompactUnit{{1e6, "\u00a0Mio."}, {1e9, "\u00a0Mrd."}, {1e12, "\u00a0Bio."}}},
+	"es":		{decimal: ",", group: ".", minimumGrouping: 2, currency: "#\u00a0¤", percent: "#\u00a0%", compact: []numberCompactUnit{{1e3, "\u00a0mil"}, {1e6, "\u00a0M"}, {1e9, "\u00a0mil\u00a0M"}, {1e12, "\u00a0B"}}},
+	"fr":		{decimal: ",", group: "\u202f", currency: "#\u00a0¤", percent: "#\u202f%", compact: []numberCompactUnit{{1e3, "\u00a0k"}, {1e6, "\u00a0M"}, {1e9, "\u00a0Md"}, {1e12, "\u00a0Bn"}}, bytes: []string{"o", "ko", "Mo", "Go", "To", "Po"}},
+	"fr-ch":	{decimal: ",", group: "\u202f", currency: "#\u00a0¤", percent: "#%", compact: []numberCompactUnit{{1e3, "\u00a0k"}, {1e6, "\u00a0M"}, {1e9, "\u00a0Md"}, {1e12, "\u00a0Bn"}}, bytes: []string{"o", "ko", "Mo", "Go", "To", "Po"}},
+	"hi":		{decimal: ".", group: ",", indian: true, currency: "¤#", percent: "#%", compact: []numberCompactUnit{{1e3, "\u00a0हज़ार"}, {1e5, "\u00a0लाख"}, {1e7, "\u00a0क॰"}}},
+	"it":		{decimal: ",", group: ".", currency: "#\u00a0¤", percent: "#%", compact: []numberCompactUnit{{1e6, "\u00a0Mln"}, {1e9, "\u00a0Mrd"}, {1e12, "\u00a0Bln"}}},
+	"ja":		{decimal: ".", group: ",", currency: "¤#", percent: "#%", compact: []numberCompactUnit{{1e4, "万"}, {1e8, "億"}, {1e12, "兆"}}},
+	"ko":		{decimal: ".", group: ",", currency: "¤#", percent: "#%", compact: []numberCompactUnit{{1e3, "천"}, {1e4, "만"}, {1e8, "억"}, {1e12, "조"}}},
+	"nl":		{decimal: ",", group: ".", currency: "¤\u00a0#", percent: "#%", compact: []numberCompactUnit{{1e3, "K"}, {1e6, "\u00a0mln."}, {1e9, "\u00a0mld."}, {1e12, "\u00a0bln."}}},
+	"pl":		{decimal: ",", group: "\u00a0", minimumGrouping: 2, currency: "#\u00a0¤", percent: "#%", compact: []numberCompactUnit{{1e3, "\u00a0tys."}, {1e6, "\u00a0mln"}, {1e9, "\u00a0mld"}, {1e12, "\u00a0bln"}}},
+	"pt":		{decimal: ",", group: ".", currency: "¤\u00a0#", percent: "#%", compact: []numberCompactUnit{{1e3, "\u00a0mil"}, {1e6, "\u00a0mi"}, {1e9, "\u00a0bi"}, {1e12, "\u00a0tri"}}},
+	"pt-pt":	{decimal: ",", group: "\u00a0", minimumGrouping: 2, currency: "#\u00a0¤", percent: "#%", compact: []numberCompactUnit{{1e3, "\u00a0mil"}, {1e6, "\u00a0M"}, {1e9, "\u00a0mM"}, {1e12, "\u00a0Bi"}}},
+	"ru":		{decimal: ",", group: "\u00a0", currency: "#\u00a0¤", percent: "#\u00a0%", compact: []numberCompactUnit{{1e3, "\u00a0тыс."}, {1e6, "\u00a0млн"}, {1e9, "\u00a0млрд"}, {1e12, "\u00a0трлн"}}, bytes: []string{"Б", "кБ", "МБ", "ГБ", "ТБ", "ПБ"}},
+	"sv":		{decimal: ",", group: "\u00a0", currency: "#\u00a0¤", percent: "#\u00a0%", compact: []numberCompactUnit{{1e3, "\u00a0tn"}, {1e6, "\u00a0mn"}, {1e9, "\u00a0md"}, {1e12, "\u00a0bn"}}},
+	"zh":		{decimal: ".", group: ",", currency: "¤#", percent: "#%", compact: []numberCompactUnit{{1e4, "万"}, {1e8, "亿"}, {1e12, "万亿"}}},
+}
+
+// The symbols of common currencies (others are shown using their code)
+var numberCurrencySymbols = map[string]string{
+	"AUD": "A$", "BRL": "R$", "CAD": "CA$", "CNY": "CN¥", "EUR": "€", "GBP": "£", "INR": "₹", "JPY": "¥", "KRW": "₩",
+	"MXN": "MX$", "NZD": "NZ$", "PLN": "zł", "RUB": "₽", "USD": "$",
+}
+
+// Currencies which do not use two decimal places
+var numberCurrencyDecimals = map[string]int{
+	"BHD": 3, "CLP": 0, "ISK": 0, "JOD": 3, "JPY": 0, "KRW": 0, "KWD": 3, "OMR": 3, "TND": 3, "VND": 0,
+}
+
+// Finds the number format of a locale: its own, its language's or, failing those, English
+func numberLocale(locale string) numberSymbols {
+	locale = normaliseLocale(locale)
+	if symbols, ok := numberLocales[locale]; ok {
+		return symbols
+	}
+
+	language, _, _ := strings.Cut(locale, "-")
+	if symbols, ok := numberLocales[language]; ok {
+		return symbols
+	}
+
+	return numberLocales["en"]
+}
+
+// Converts a number to a plain string of digits (e.g. "-1234.50"). If `fixed`, it has exactly `decimals` decimal
+// places, otherwise it has at most `decimals` and trailing zeros are removed. Integers are converted exactly
+func numberDigits(value reflect.Value, decimals int, fixed bool) (string, error) {
+	digits := ""
+
+	switch value.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			digits = strconv.FormatInt(value.Int(), 10)
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			digits = strconv.FormatUint(value.Uint(), 10)
+		default:
+			number, err := reflectHelperConvertToFloat64(value)
+			if err != nil {
+				return "", err
+			}
+			return numberFloatDigits(number, decimals, fixed)
+	}
+
+	if fixed && decimals > 0 {
+		digits += "." + strings.Repeat("0", decimals)
+	}
+
+	return digits, nil
+}
+
+// Converts a float to a plain string of digits (see `numberDigits()`)
+func numberFloatDigits(number float64, decimals int, fixed bool) (string, error) {
+	if math.IsNaN(number) || math.IsInf(number, 0) {
+		return "", fmt.Errorf("can't format %v as a number", number)
+	}
+
+	digits := strconv.FormatFloat(number, 'f', decimals, 64)
+	if !fixed && strings.Contains(digits, ".") {
+		digits = strings.TrimRight(strings.TrimRight(digits, "0"), ".")
+	}
+	if strings.Trim(digits, "-0.") == "" {
+		digits = strings.TrimPrefix(digits, "-")
+	}
+
+	return digits, nil
+}
+
+// Applies a locale's decimal and grouping separators to a plain string of digits
+func (s numberSymbols) formatDigits(digits string) string {
+	sign := ""
+	if strings.HasPrefix(digits, "-") {
+		sign, digits = "-", digits[1:]
+	}
+
+	integer, fraction, hasFraction := strings.Cut(digits, ".")
+
+	minimum := s.minimumGrouping
+	if minimum < 1 {
+		minimum = 1
+	}
+
+	if len(integer) >= 3 + minimum {
+		groups := []string{integer[len(integer) - 3:]}
+		integer = integer[:len(integer) - 3]
+
+		size := 3
+		if s.indian {
+			size = 2
+		}
+		for len(integer) > size {
+			groups = append([]string{integer[len(integer) - size:]}, groups...)
+			integer = integer[:len(integer) - size]
+		}
+		integer = strings.Join(append([]string{integer}, groups...), s.group)
+	}
+
+	if hasFraction {
+		return sign + integer + s.decimal + fraction
+	}
+
+	return sign + integer
+}
+
+// Places a formatted number into a pattern, with a non-breaking space between the number and a symbol that would
+// otherwise run into it (e.g. "CHF" in "CHF 10.00")
+func numberPattern(pattern string, symbol string, number string) string {
+	sign := ""
+	if strings.HasPrefix(number, "-") {
+		sign, number = "-", number[1:]
+	}
+
+	if strings.Contains(pattern, "¤#") {
+		if last, _ := utf8.DecodeLastRuneInString(symbol); unicode.IsLetter(last) {
+			pattern = strings.Replace(pattern, "¤#", "¤\u00a0#", 1)
+		}
+	} else if strings.Contains(pattern, "#¤") {
+		if first, _ := utf8.DecodeRuneInString(symbol); unicode.IsLetter(first) {
+			pattern = strings.Replace(pattern, "#¤", "#\u00a0¤", 1)
+		}
+	}
+
+	return sign + strings.NewReplacer("¤", symbol, "#", number).Replace(pattern)
+}
+
+// Reads the optional leading decimal places argument of the number functions, returning the value (the last
+// argument) and the decimal places (`decimals` if they were not given)
+func numberArguments(sig string, args []reflect.Value, decimals int) (reflect.Value, int, bool, error) {
+	if len(args) < 1 || len(args) > 2 {
+		return reflect.Value{}, 0, false, fmt.Errorf("%s expects 1 or 2 arguments, received %d", sig, len(args))
+	}
+
+	value := reflectHelperUnpackInterface(args[len(args) - 1])
+	if len(args) == 1 {
+		return value, decimals, false, nil
+	}
+
+	precision := reflectHelperUnpackInterface(args[0])
+	if !precision.IsValid() || !reflectHelperIsInteger(precision) {
+		return value, 0, false, fmt.Errorf("%s decimals can only be an integer", sig)
+	}
+
+	places, err := reflectHelperConvertToUint(precision)
+	if err != nil || places > 20 {
+		return value, 0, false, fmt.Errorf("%s decimals must be between 0 and 20", sig)
+	}
+
+	return value, int(places), true, nil
+}
+
+// Formats a number for a locale (see `number()`)
+func numberFormat(locale string, args ...reflect.Value) (string, error) {
+	sig := "number(decimals int, value any)"
+
+	value, decimals, fixed, err := numberArguments(sig, args, 3)
+	if err != nil {
+		err = logError(err.Error())
+		return "", err
+	}
+
+	digits, err := numberDigits(value, decimals, fixed)
+	if err != nil {
+		err = logError(sig + " " + err.Error())
+		return "", err
+	}
+
+	return numberLocale(locale).formatDigits(digits), nil
+}
+
+// Formats an amount of a currency for a locale (see `currency()`)
+func numberCurrency(locale string, code reflect.Value, args ...reflect.Value) (string, error) {
+	sig := "currency(code string, decimals int, value any)"
+
+	code = reflectHelperUnpackInterface(code)
+	if !code.IsValid() || code.Kind() != reflect.String {
+		err := logError(sig + " code must be a string")
+		return "", err
+	}
+
+	currency := strings.ToUpper(strings.TrimSpace(code.String()))
+	decimals, ok := numberCurrencyDecimals[currency]
+	if !ok {
+		decimals = 2
+	}
+
+	value, decimals, _, err := numberArguments(sig, args, decimals)
+	if err != nil {
+		err = logError(err.Error())
+		return "", err
+	}
+
+	digits, err := numberDigits(value, decimals, true)
+	if err != nil {
+		err = logError(sig + " " + err.Error())
+		return "", err
+	}
+
+	symbol, ok := numberCurrencySymbols[currency]
+	if !ok {
+		symbol = currency
+	}
+
+	symbols := numberLocale(locale)
+
+	return numberPattern(symbols.currency, symbol, symbols.formatDigits(digits)), nil
+}
+
+// Formats a ratio as a percentage for a locale (see `percent()`)
+func numberPercent(locale string, args ...reflect.Value) (string, error) {
+	sig := "percent(decimals int, value any)"
+
+	value, decimals, _, err := numberArguments(sig, args, 0)
+	if err != nil {
+		err = logError(err.Error())
+		return "", err
+	}
+
+	number, err := reflectHelperConvertToFloat64(value)
+	if err == nil {
+		var digits string
+		digits, err = numberFloatDigits(number * 100, decimals, true)
+		if err == nil {
+			symbols := numberLocale(locale)
+			return numberPattern(symbols.percent, "%", symbols.formatDigits(digits)), nil
+		}
+	}
+
+	err = logError(sig + " " + err.Error())
+	return "", err
+}
+
+// Abbreviates a large number for a locale (see `compact()`)
+func numberCompact(locale string, args ...reflect.Value) (string, error) {
+	sig := "compact(decimals int, value any)"
+
+	value, decimals, _, err := numberArguments(sig, args, 1)
+	if err != nil {
+		err = logError(err.Error())
+		return "", err
+	}
+
+	number, err := reflectHelperConvertToFloat64(value)
+	if err != nil {
+		err = logError(sig + " " + err.Error())
+		return "", err
+	}
+
+	symbols := numberLocale(locale)
+	units := append([]numberCompactUnit{{1, ""}}, symbols.compact...)
+
+	unit := 0
+	for i, compact := range units {
+		if math.Abs(number) >= compact.size {
+			unit = i
+		}
+	}
+
+	digits, err := numberFloatDigits(number / units[unit].size, decimals, false)
+	if err != nil {
+		err = logError(sig + " " + err.Error())
+		return "", err
+	}
+
+	// Rounding may carry the number into the next unit (e.g. 999,999 is "1M" rather than "1000K")
+	if unit + 1 < len(units) {
+		rounded, _ := strconv.ParseFloat(digits, 64)
+		if math.Abs(rounded) * units[unit].size >= units[unit + 1].size {
+			unit++
+			digits, _ = numberFloatDigits(number / units[unit].size, decimals, false)
+		}
+	}
+
+	return symbols.formatDigits(digits) + units[unit].suffix, nil
+}
+
+// Formats a number of bytes for a locale (see `filesize()`)
+func numberFilesize(locale string, args ...reflect.Value) (string, error) {
+	sig := "filesize(decimals int, value any)"
+
+	value, decimals, _, err := numberArguments(sig, args, 1)
+	if err != nil {
+		err = logError(err.Error())
+		return "", err
+	}
+
+	bytes, err := reflectHelperConvertToFloat64(value)
+	if err != nil {
+		err = logError(sig + " " + err.Error())
+		return "", err
+	}
+
+	symbols := numberLocale(locale)
+	units := symbols.bytes
+	if len(units) == 0 {
+		units = numberEnglishByteUnits
+	}
+
+	unit := 0
+	for unit + 1 < len(units) && math.Abs(bytes) >= math.Pow(1000, float64(unit + 1)) {
+		unit++
+	}
+
+	places := decimals
+	if unit == 0 {
+		places = 0
+	}
+
+	digits, err := numberFloatDigits(bytes / math.Pow(1000, float64(unit)), places, false)
+	if err != nil {
+		err = logError(sig + " " + err.Error())
+		return "", err
+	}
+
+	// Rounding may carry the size into the next unit (e.g. 999,999 bytes is "1 MB" rather than "1000 kB")
+	if rounded, _ := strconv.ParseFloat(digits, 64); unit + 1 < len(units) && math.Abs(rounded) >= 1000 {
+		unit++
+		digits, _ = numberFloatDigits(bytes / math.Pow(1000, float64(unit)), decimals, false)
+	}
+
+	return symbols.formatDigits(digits) + " " + units[unit], nil
+}
+
+// Creates versions of the number formatting template functions (under whatever names they are registered with) for a
+// locale ("" for the default locale)
+func (tm *TemplateManager) numberFunctions(locale string) map[string]any {
+	current := func() string {
+		if len(locale) == 0 {
+			return tm.defaultLocale
+		}
+		return locale
+	}
+
+	functions := map[string]any{}
+	for _, name := range tm.builtinFunctionNames(compact) {
+		functions[name] = func(args ...reflect.Value) (string, error) {
+			return numberCompact(current(), args...)
+		}
+	}
+	for _, name := range tm.builtinFunctionNames(currency) {
+		functions[name] = func(code reflect.Value, args ...reflect.Value) (string, error) {
+			return numberCurrency(current(), code, args...)
+		}
+	}
+	for _, name := range tm.builtinFunctionNames(filesize) {
+		functions[name] = func(args ...reflect.Value) (string, error) {
+			return numberFilesize(current(), args...)
+		}
+	}
+	for _, name := range tm.builtinFunctionNames(number) {
+		functions[name] = func(args ...reflect.Value) (string, error) {
+			return numberFormat(current(), args...)
+		}
+	}
+	for _, name := range tm.builtinFunctionNames(percent) {
+		functions[name] = func(args ...reflect.Value) (string, error) {
+			return numberPercent(current(), args...)
+		}
+	}
+
+	return functions
+}
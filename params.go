@@ -0,0 +1,291 @@
+package templateManager
+
+/*
+Functions dedicated to merging variables from all of their sources, following a fixed precedence
+*/
+
+import (
+	"fmt"
+	"path"
+	"reflect"
+	"strings"
+
+	"golang.org/x/exp/slices"
+)
+
+// A variable declared within a template file (by `var` or front matter)
+type templateVar struct {
+	value		any
+	modifier	string
+}
+
+// A set of variables from a single source. Layers are merged in order, each overriding (or appending to) the last
+type paramLayer struct {
+	source	string
+	params	Params
+	append	bool
+}
+
+// The modifiers that may follow the name of a `var`
+var varModifiers = []string{"default", "override", "append"}
+
+// Lists every layer of variables used by the `name` template, from the lowest precedence to the highest:
+//
+//	1. the data directory (`.Data`)
+//	2. `default` vars
+//	3. global params
+//	4. directory params (from the templates directory down to the template's own, `_params.json` above Go)
+//	5. params attached in Go and then normal vars (from the deepest file in the bundle up to the entry template)
+//	6. `override` vars
+//	7. `append` vars (appended to the value built so far)
+//	8. request params (from context processors)
+//	9. render data
+//
+// Within the levels set by files, files nearer to the entry template take precedence over those they extend / include
+func (tm *TemplateManager) paramLayers(name string, data Params, requestParams Params) []paramLayer {
+	layers := []paramLayer{}
+
+	if len(tm.dataDirectory) > 0 {
+		layers = append(layers, paramLayer{source: "data directory", params: Params{"Data": tm.data}})
+	}
+
+	files := append([]string{name}, tm.descendants[name]...)
+	bundle := []string{}
+	for i := len(files) - 1; i >= 0; i-- {
+		if !slices.Contains(bundle, files[i]) {
+			bundle = append(bundle, files[i])
+		}
+	}
+
+	for _, file := range bundle {
+		layers = append(layers, paramLayer{source: "default var in " + file, params: tm.fileVars(file, "default")})
+	}
+
+	layers = append(layers, paramLayer{source: "global", params: tm.globalParams})
+
+	for _, directory := range templateDirectories(name) {
+		label := directory
+		if len(label) == 0 {
+			label = "."
+		}
+
+		layers = append(layers, paramLayer{source: "directory " + label, params: tm.directoryParams[directory]})
+		layers = append(layers, paramLayer{source: path.Join(directory, directoryParamsFile), params: tm.directoryFileParams[directory]})
+	}
+
+	for _, file := range bundle {
+		layers = append(layers, paramLayer{source: "AddParam on " + file, params: tm.params[file]})
+		layers = append(layers, paramLayer{source: "var in " + file, params: tm.fileVars(file, "")})
+	}
+
+	for _, file := range bundle {
+		layers = append(layers, paramLayer{source: "override var in " + file, params: tm.fileVars(file, "override")})
+	}
+
+	for _, file := range bundle {
+		layers = append(layers, paramLayer{source: "append var in " + file, params: tm.fileVars(file, "append"), append: true})
+	}
+
+	layers = append(layers, paramLayer{source: "request", params: requestParams})
+	layers = append(layers, paramLayer{source: "render", params: data})
+
+	return layers
+}
+
+// Finds all vars declared in a single file with the given modifier
+func (tm *TemplateManager) fileVars(file string, modifier string) Params {
+	params := Params{}
+	for name, variable := range tm.vars[file] {
+		if variable.modifier == modifier {
+			params[name] = variable.value
+		}
+	}
+
+	return params
+}
+
+// Merges all layers into a single new `Params` map
+func mergeParamLayers(layers []paramLayer) Params {
+	params := Params{}
+
+	for _, layer := range layers {
+		for key, value := range layer.params {
+			if layer.append {
+				params[key] = appendParam(params[key], value)
+			} else {
+				params[key] = value
+			}
+		}
+	}
+
+	return params
+}
+
+// Appends `value` (a slice or a single value) to `base`. If all values fit the type of `base` the result keeps that
+// type, otherwise it is an `[]any`
+func appendParam(base any, value any) any {
+	if base == nil {
+		return value
+	}
+
+	baseValue	:= reflect.ValueOf(base)
+	values		:= paramElements(value)
+
+	if baseValue.Kind() == reflect.Slice {
+		result := reflect.MakeSlice(baseValue.Type(), 0, baseValue.Len() + len(values))
+		result = reflect.AppendSlice(result, baseValue)
+
+		typed := true
+		for _, element := range values {
+			if !element.IsValid() || !element.Type().AssignableTo(baseValue.Type().Elem()) {
+				typed = false
+				break
+			}
+			result = reflect.Append(result, element)
+		}
+
+		if typed {
+			return result.Interface()
+		}
+	}
+
+	result := []any{}
+	for _, element := range append(paramElements(base), values...) {
+		if element.IsValid() {
+			result = append(result, element.Interface())
+		} else {
+			result = append(result, nil)
+		}
+	}
+
+	return result
+}
+
+// Lists the elements of a slice (or the value itself if it is not a slice)
+func paramElements(value any) []reflect.Value {
+	reflected := reflect.ValueOf(value)
+	if reflected.Kind() != reflect.Slice {
+		return []reflect.Value{reflected}
+	}
+
+	elements := []reflect.Value{}
+	for i := 0; i < reflected.Len(); i++ {
+		elements = append(elements, reflect.ValueOf(reflected.Index(i).Interface()))
+	}
+
+	return elements
+}
+
+// Checks the modifiers given to a `var` and returns the precedence modifier that applies ("" if there is none)
+// and whether the var is evaluated at render time
+func parseVarModifiers(modifiers string) (string, bool, error) {
+	modifier	:= ""
+	eval		:= false
+
+	for _, word := range strings.Fields(modifiers) {
+		if word == "eval" && !eval {
+			eval = true
+			continue
+		}
+
+		if !slices.Contains(varModifiers, word) {
+			return "", false, fmt.Errorf("unknown modifier %q", word)
+		}
+
+		if len(modifier) > 0 {
+			return "", false, fmt.Errorf("modifiers %q and %q cannot be combined", modifier, word)
+		}
+
+		modifier = word
+	}
+
+	if eval && modifier == "append" {
+		return "", false, fmt.Errorf("modifiers %q and %q cannot be combined", "eval", modifier)
+	}
+
+	return modifier, eval, nil
+}
+
+// Describes where a single variable's value came from, as returned by `ExplainParams()`
+type ParamExplanation struct {
+	Name		string
+	Value		any
+	Source		string
+	Overridden	[]ParamValue
+}
+
+// A value given to a variable by a single source
+type ParamValue struct {
+	Value	any
+	Source	string
+}
+
+// Merges all layers (in the same way as `mergeParamLayers()`), recording the source of every value and the values
+// that each one replaced
+func explainParamLayers(layers []paramLayer) map[string]*ParamExplanation {
+	explanations := map[string]*ParamExplanation{}
+
+	for _, layer := range layers {
+		for key, value := range layer.params {
+			source := layer.source
+			if _, ok := value.(evalVar); ok {
+				source = "eval " + source
+			}
+
+			explanation, ok := explanations[key]
+			if !ok {
+				explanations[key] = &ParamExplanation{Name: key, Value: value, Source: source, Overridden: []ParamValue{}}
+				continue
+			}
+
+			explanation.Overridden = append(explanation.Overridden, ParamValue{Value: explanation.Value, Source: explanation.Source})
+			explanation.Source = source
+
+			if layer.append {
+				explanation.Value = appendParam(explanation.Value, value)
+			} else {
+				explanation.Value = value
+			}
+		}
+	}
+
+	return explanations
+}
+
+// Formats explanations as a table for debugging output
+func formatParamExplanations(explanations []ParamExplanation) string {
+	nameWidth, valueWidth := 0, 0
+	values := make([]string, len(explanations))
+
+	for i, explanation := range explanations {
+		values[i] = formatParamValue(explanation.Value)
+		if len(explanation.Name) > nameWidth {
+			nameWidth = len(explanation.Name)
+		}
+		if len(values[i]) > valueWidth {
+			valueWidth = len(values[i])
+		}
+	}
+
+	table := ""
+	for i, explanation := range explanations {
+		table += fmt.Sprintf("\t%-*s = %-*s  [%s]\n", nameWidth, explanation.Name, valueWidth, values[i], explanation.Source)
+
+		for j := len(explanation.Overridden) - 1; j >= 0; j-- {
+			overridden := explanation.Overridden[j]
+			table += fmt.Sprintf("\t%-*s   %-*s  [%s]\n", nameWidth, "", valueWidth, "^ " + formatParamValue(overridden.Value), overridden.Source)
+		}
+	}
+
+	return table
+}
+
+// Formats a single value (with its type) for debugging output, shortening long values
+func formatParamValue(value any) string {
+	formatted := fmt.Sprintf("%v", value)
+	if len(formatted) > 60 {
+		formatted = formatted[:57] + "..."
+	}
+
+	return fmt.Sprintf("%s (%T)", formatted, value)
+}
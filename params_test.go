@@ -0,0 +1,590 @@
+package templateManager
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestAAParamsSetup(tester  *testing.T) {
+	testsShowDetails	= true
+	testsShowSuccessful = false
+	consoleErrors		= false
+	consoleWarnings		= false
+	haltOnErrors		= false
+	haltOnWarnings		= false
+
+	testFormatTitle("params")
+}
+
+// Creates a `TemplateManager` with variables set at every level of precedence
+func testParamsTemplateManager(tester *testing.T) *TemplateManager {
+	files := fstest.MapFS{
+		"templates/layouts/main.html": {Data: []byte(`
+			{{ var "Weak" default }} layout-default {{ end }}
+			{{ var "Forced" override }} layout-override {{ end }}
+			{{ var "Normal" }} layout {{ end }}
+			{{ var "AttachedOnly" }} layout {{ end }}
+			{{ var "Scripts" }} ["main.js"] {{ end }}
+			{{ block "content" . }}{{ end }}
+		`)},
+		"templates/partials/nav.html": {Data: []byte(`
+			{{ var "Weak" default }} partial-default {{ end }}
+			{{ var "GlobalBeatsDefault" default }} partial-default {{ end }}
+			{{ var "Scripts" append }} ["nav.js"] {{ end }}
+		`)},
+		"templates/pages/_params.json": {Data: []byte(`{"DirectoryFile": "file", "Normal": "directory"}`)},
+		"templates/pages/index.html": {Data: []byte(`---
+FrontMatter: page
+---
+			{{ extends "layouts/main.html" }}
+			{{ var "Weak" default }} page-default {{ end }}
+			{{ var "Forced" }} page {{ end }}
+			{{ var "Normal" }} page {{ end }}
+			{{ var "Attached" }} page {{ end }}
+			{{ var "Scripts" append }} ["page.js"] {{ end }}
+			{{ var "Evaluated" eval }} printf "%s/%s" .Normal .RenderWins {{ end }}
+			{{ var "EvaluatedTwice" eval }} len .Evaluated {{ end }}
+			{{ define "content" }}{{ template "partials/nav.html" . }}{{ end }}
+		`)},
+	}
+
+	tm := Init("templates", ".html")
+	tm.fileSystem = http.FS(files)
+	tm.ExcludeDirectories([]string{"layouts", "partials"})
+	tm.AddGlobalParams(Params{"GlobalBeatsDefault": "global", "Directory": "global", "RenderWins": "global"})
+	tm.AddDirectoryParams("", Params{"Directory": "root", "DirectoryFile": "code"})
+	tm.AddDirectoryParam("pages", "Directory", "pages")
+	tm.AddParams("pages/index.html", Params{"Attached": "code", "AttachedOnly": "code"})
+
+	err := tm.Parse()
+	if err != nil {
+		tester.Fatalf("\033[31mFAIL: \033[36mParse\033[0m: %s", err.Error())
+	}
+
+	return tm
+}
+
+func TestParamPrecedence(tester *testing.T) {
+	tm		:= testParamsTemplateManager(tester)
+	data	:= Params{"RenderWins": "render"}
+	request	:= Params{"RenderWins": "request", "Requested": "request"}
+	params, err := tm.buildParams("pages/index.html", data, request)
+	if err != nil {
+		tester.Fatalf("\033[31mFAIL: \033[36mbuildParams\033[0m: %s", err.Error())
+	}
+
+	tests := map[string]any{
+		"Weak":					"page-default",
+		"GlobalBeatsDefault":	"global",
+		"Directory":			"pages",
+		"DirectoryFile":		"file",
+		"Normal":				"page",
+		"Forced":				"layout-override",
+		"Attached":				"page",
+		"AttachedOnly":			"code",
+		"FrontMatter":			"page",
+		"Scripts":				[]string{"main.js", "nav.js", "page.js"},
+		"Requested":			"request",
+		"RenderWins":			"render",
+		"Evaluated":			"page/render",
+		"EvaluatedTwice":		11,
+	}
+
+	passed, failed := 0, 0
+	for key, expected := range tests {
+		if reflect.DeepEqual(params[key], expected) {
+			passed++
+		} else {
+			tester.Errorf("\033[31mFAIL: \033[36mbuildParams(%s)\033[0m:\n\t\033[31mProduced: \033[33m%#v \033[36m%T\033[0m\n\t\033[31mExpected: \033[33m%#v \033[36m%T\033[0m", key, params[key], params[key], expected, expected)
+			failed++
+		}
+	}
+
+	if !reflect.DeepEqual(data, Params{"RenderWins": "render"}) {
+		tester.Errorf("\033[31mFAIL: \033[36mbuildParams\033[0m: render data was modified: %#v", data)
+		failed++
+	}
+
+	testFormatPassFail("buildParams", passed, failed)
+}
+
+func TestExplainParams(tester *testing.T) {
+	tm := testParamsTemplateManager(tester)
+
+	explanations, err := tm.ExplainParams("pages/index.html", Params{"RenderWins": "render"})
+	if err != nil {
+		tester.Fatalf("\033[31mFAIL: \033[36mExplainParams\033[0m: %s", err.Error())
+	}
+
+	tests := map[string]ParamExplanation{
+		"Normal": {"Normal", "page", "var in pages/index.html", []ParamValue{{"directory", "pages/_params.json"}, {"layout", "var in layouts/main.html"}}},
+		"RenderWins": {"RenderWins", "render", "render", []ParamValue{{"global", "global"}}},
+		"AttachedOnly": {"AttachedOnly", "code", "AddParam on pages/index.html", []ParamValue{{"layout", "var in layouts/main.html"}}},
+		"Evaluated": {"Evaluated", "page/render", "eval var in pages/index.html", []ParamValue{}},
+	}
+
+	passed, failed := 0, 0
+	for _, explanation := range explanations {
+		expected, ok := tests[explanation.Name]
+		if !ok {
+			continue
+		}
+
+		if reflect.DeepEqual(explanation, expected) {
+			passed++
+		} else {
+			tester.Errorf("\033[31mFAIL: \033[36mExplainParams(%s)\033[0m:\n\t\033[31mProduced: \033[33m%#v\033[0m\n\t\033[31mExpected: \033[33m%#v\033[0m", explanation.Name, explanation, expected)
+			failed++
+		}
+	}
+
+	if passed + failed != len(tests) {
+		tester.Errorf("\033[31mFAIL: \033[36mExplainParams\033[0m: only %d of %d variables were explained", passed + failed, len(tests))
+		failed++
+	}
+
+	testFormatPassFail("ExplainParams", passed, failed)
+}
+
+func TestAppendParam(tester *testing.T) {
+	tests := []struct{ base, value, expected any }{
+		{nil, []string{"a"}, []string{"a"}},
+		{[]string{"a"}, []string{"b", "c"}, []string{"a", "b", "c"}},
+		{[]string{"a"}, "b", []string{"a", "b"}},
+		{[]int{1}, []float64{2.5}, []any{1, 2.5}},
+		{"a", []string{"b"}, []any{"a", "b"}},
+		{[]any{"a"}, []int{1}, []any{"a", 1}},
+	}
+
+	passed, failed := 0, 0
+	for _, test := range tests {
+		result := appendParam(test.base, test.value)
+		if reflect.DeepEqual(result, test.expected) {
+			passed++
+		} else {
+			tester.Errorf("\033[31mFAIL: \033[36mappendParam(%#v, %#v)\033[0m:\n\t\033[31mProduced: \033[33m%#v \033[36m%T\033[0m\n\t\033[31mExpected: \033[33m%#v \033[36m%T\033[0m", test.base, test.value, result, result, test.expected, test.expected)
+			failed++
+		}
+	}
+
+	testFormatPassFail("appendParam", passed, failed)
+}
+
+func TestPipelineDependencies(tester *testing.T) {
+	tests := map[string][]string{
+		`.BaseUrl`:							{"BaseUrl"},
+		`.BaseUrl | suffix .Page.Path`:		{"BaseUrl", "Page"},
+		`printf "%s" $.Title (len .Items)`:	{"Title", "Items"},
+		`"literal"`:						{},
+	}
+
+	passed, failed := 0, 0
+	for pipeline, expected := range tests {
+		result, err := pipelineDependencies(pipeline, "{{", "}}")
+		if err == nil && reflect.DeepEqual(result, expected) {
+			passed++
+		} else {
+			tester.Errorf("\033[31mFAIL: \033[36mpipelineDependencies(%s)\033[0m:\n\t\033[31mProduced: \033[33m%#v (%v)\033[0m\n\t\033[31mExpected: \033[33m%#v\033[0m", pipeline, result, err, expected)
+			failed++
+		}
+	}
+
+	testFormatPassFail("pipelineDependencies", passed, failed)
+}
+
+func TestSchema(tester *testing.T) {
+	files := fstest.MapFS{
+		"templates/layouts/main.html": {Data: []byte(`
+			{{ var "SiteName" }} "Site" {{ end }}
+			{{ .Title }} {{ .SiteName }}
+			{{ block "content" . }}{{ end }}
+			{{ template "partials/user.html" .User }}
+		`)},
+		"templates/partials/user.html": {Data: []byte(`{{ .Name }} {{ with .Address }}{{ .City }}{{ end }}`)},
+		"templates/pages/index.html": {Data: []byte(`
+			{{ extends "layouts/main.html" }}
+			{{ define "content" }}
+				{{ range $i, $item := .Items }}{{ $item.Url }} {{ .Label }} {{ $.Footer }}{{ end }}
+				{{ $page := .Page }}{{ $page.Author.Email | printf "%s" }}
+			{{ end }}
+		`)},
+	}
+
+	tm := Init("templates", ".html")
+	tm.fileSystem = http.FS(files)
+
+	schema, err := tm.Schema("pages/index.html")
+	if err != nil {
+		tester.Fatalf("\033[31mFAIL: \033[36mSchema\033[0m: %s", err.Error())
+	}
+
+	tests := map[string]any{
+		"Paths": []string{"Footer", "Items[]", "Items[].Label", "Items[].Url", "Page", "Page.Author", "Page.Author.Email", "SiteName", "Title", "User", "User.Address", "User.Address.City", "User.Name"},
+		"Required": []string{"Footer", "Items", "Page", "Title", "User"},
+		"Missing": []string{"Items", "Page", "User"},
+	}
+
+	results := map[string]any{
+		"Paths": schema.Paths(),
+		"Required": schema.Required(),
+		"Missing": missingSchemaFields(schema, Params{"Title": "", "SiteName": "", "Footer": nil}),
+	}
+
+	passed, failed := 0, 0
+	for key, expected := range tests {
+		if reflect.DeepEqual(results[key], expected) {
+			passed++
+		} else {
+			tester.Errorf("\033[31mFAIL: \033[36mSchema(%s)\033[0m:\n\t\033[31mProduced: \033[33m%#v\033[0m\n\t\033[31mExpected: \033[33m%#v\033[0m", key, results[key], expected)
+			failed++
+		}
+	}
+
+	testFormatPassFail("Schema", passed, failed)
+}
+
+type testViewItem struct {
+	Url		string
+}
+
+type testView struct {
+	Title	string
+	Items	[]testViewItem
+}
+
+func (v testView) Count() int {
+	return len(v.Items)
+}
+
+type testViewTypo struct {
+	Titel	string
+	Items	[]struct{ Link string }
+}
+
+func TestRegisterView(tester *testing.T) {
+	files := fstest.MapFS{
+		"templates/index.html": {Data: []byte(`{{ var "Site" }} site {{ end }}{{ .Site }}:{{ .Title }}:{{ .Count }}:{{ range .Items }}{{ .Url }}{{ end }}`)},
+	}
+
+	passed, failed := 0, 0
+
+	tm := Init("templates", ".html")
+	tm.fileSystem = http.FS(files)
+	view := RegisterView[testView](tm, "index.html")
+
+	buf := &bytes.Buffer{}
+	err := view.Render(buf, testView{Title: "Home", Items: []testViewItem{{"a"}, {"b"}}})
+	if err == nil && buf.String() == "site:Home:2:ab" {
+		passed++
+	} else {
+		tester.Errorf("\033[31mFAIL: \033[36mView.Render\033[0m:\n\t\033[31mProduced: \033[33m%q (%v)\033[0m", buf.String(), err)
+		failed++
+	}
+
+	tm = Init("templates", ".html")
+	tm.fileSystem = http.FS(files)
+	RegisterView[*testViewTypo](tm, "index.html")
+
+	err = tm.Parse()
+	expected := "view index.html: *templateManager.testViewTypo does not have the fields used by the template: Count, Items[].Url, Title"
+	if err != nil && err.Error() == expected {
+		passed++
+	} else {
+		tester.Errorf("\033[31mFAIL: \033[36mRegisterView\033[0m:\n\t\033[31mProduced: \033[33m%v\033[0m\n\t\033[31mExpected: \033[33m%s\033[0m", err, expected)
+		failed++
+	}
+
+	testFormatPassFail("RegisterView", passed, failed)
+}
+
+type testViewCalls struct {
+	Show	bool
+	Calls	*int
+}
+
+func (v testViewCalls) Expensive() (string, error) {
+	*v.Calls++
+	return "expensive", nil
+}
+
+func (v testViewCalls) Failing() (string, error) {
+	return "", fmt.Errorf("boom")
+}
+
+func TestViewMethods(tester *testing.T) {
+	files := fstest.MapFS{
+		"templates/lazy.html":		{Data: []byte(`{{ if .Show }}{{ .Expensive }} {{ len .Expensive }} {{ with $ }}{{ $.Expensive }}{{ end }}{{ end }}.`)},
+		"templates/failing.html":	{Data: []byte(`{{ if .Show }}{{ .Failing }}{{ end }}.`)},
+	}
+
+	tm := Init("templates", ".html")
+	tm.fileSystem = http.FS(files)
+	lazy := RegisterView[testViewCalls](tm, "lazy.html")
+	failing := RegisterView[testViewCalls](tm, "failing.html")
+
+	render := func(view *View[testViewCalls], show bool) []any {
+		calls := 0
+		buf := &bytes.Buffer{}
+		err := view.Render(buf, testViewCalls{Show: show, Calls: &calls})
+		if err != nil {
+			return []any{err.Error(), calls}
+		}
+		return []any{buf.String(), calls}
+	}
+
+	schema, _ := tm.Schema("lazy.html")
+
+	halt := haltOnErrors
+	haltOnErrors = true
+	failed := render(failing, true)
+	haltOnErrors = halt
+
+	reloading := Init("templates", ".html").Reload(true)
+	reloading.fileSystem = http.FS(files)
+	reloaded := RegisterView[testViewCalls](reloading, "lazy.html")
+
+	files["templates/reload.html"] = &fstest.MapFile{Data: []byte(`{{ .Show }}`)}
+	edited := RegisterView[testViewCalls](reloading, "reload.html")
+	before := render(edited, true)
+	files["templates/reload.html"] = &fstest.MapFile{Data: []byte(`{{ .Expensive }}`)}
+	after := render(edited, true)
+	delete(files, "templates/reload.html")
+
+	tests := []struct { inputs []any; result any; expected any }{
+		{[]any{"lazy.html", "unused"}, render(lazy, false), []any{".", 0}},
+		{[]any{"lazy.html", "Reload"}, render(reloaded, true), []any{"expensive 9 expensive.", 1}},
+		{[]any{"reload.html", "Reload", "before"}, before, []any{"true", 0}},
+		{[]any{"reload.html", "Reload", "after"}, after, []any{"expensive", 1}},
+		{[]any{"lazy.html", "used"}, render(lazy, true), []any{"expensive 9 expensive.", 1}},
+		{[]any{"lazy.html", "Schema"}, schema.Paths(), []string{"Expensive", "Show"}},
+		{[]any{"failing.html", "unused"}, render(failing, false), []any{".", 0}},
+		{[]any{"failing.html", "used"}, strings.Contains(failed[0].(string), "view failing.html: method Failing: boom"), true},
+	}
+
+	testRunTests("ViewMethods", tests, tester)
+}
+
+func TestFrontMatter(tester *testing.T) {
+	files := fstest.MapFS{
+		"templates/yaml.html": {Data: []byte("---\nTitle: YAML page\nTags: [a, b]\nWeight: 2\n---\n{{ .Title }}|{{ range .Tags }}{{ . }}{{ end }}|{{ .Weight }}")},
+		"templates/toml.html": {Data: []byte("+++\nTitle = \"TOML page\"\nDraft = true\n+++\n{{ .Title }}|{{ .Draft }}")},
+		"templates/json.html": {Data: []byte("---\n{\"Title\": \"JSON page\", \"Price\": 1.5}\n---\n{{ .Title }}|{{ .Price }}")},
+		"templates/var.html": {Data: []byte("\uFEFF---\nHeading: Front matter\n---\n{{ var \"Heading\" }} Var {{ end }}{{ .Heading }}")},
+		"templates/none.html": {Data: []byte("---not front matter\n{{ .Title }}")},
+	}
+
+	tm := Init("templates", ".html")
+	tm.fileSystem = http.FS(files)
+
+	render := func(name string) string {
+		buf := &bytes.Buffer{}
+		err := tm.Render(name, Params{"Title": "render"}, buf)
+		if err != nil {
+			return err.Error()
+		}
+		return buf.String()
+	}
+
+	tests := []struct { inputs []any; result any; expected any }{
+		{[]any{"yaml.html", "Meta"}, tm.Meta("yaml.html"), Params{"Title": "YAML page", "Tags": []string{"a", "b"}, "Weight": 2}},
+		{[]any{"toml.html", "Meta"}, tm.Meta("toml.html"), Params{"Title": "TOML page", "Draft": true}},
+		{[]any{"json.html", "Meta"}, tm.Meta("json.html"), Params{"Title": "JSON page", "Price": 1.5}},
+		{[]any{"none.html", "Meta"}, tm.Meta("none.html"), Params{}},
+		{[]any{"yaml.html"}, render("yaml.html"), "render|ab|2"},
+		{[]any{"toml.html"}, render("toml.html"), "render|true"},
+		{[]any{"json.html"}, render("json.html"), "render|1.5"},
+		{[]any{"var.html", "var wins"}, tm.Meta("var.html")["Heading"].(string) + "|" + render("var.html"), "Front matter|Var"},
+		{[]any{"none.html"}, render("none.html"), "---not front matter\nrender"},
+	}
+
+	invalid := Init("templates", ".html")
+	invalid.fileSystem = http.FS(fstest.MapFS{"templates/index.html": {Data: []byte("---\nTitle: [unclosed\n---\n")}})
+	err := invalid.Parse()
+	tests = append(tests, struct { inputs []any; result any; expected any }{[]any{"invalid yaml"}, err != nil && strings.HasPrefix(err.Error(), "index.html: invalid yaml front matter: "), true})
+
+	testRunTests("frontMatter", tests, tester)
+}
+
+func TestDataDirectory(tester *testing.T) {
+	files := fstest.MapFS{
+		"data/site.json":			{Data: []byte(`{"Name": "Site", "Year": 2024}`)},
+		"data/nav/main.yaml":		{Data: []byte("- Home\n- About\n")},
+		"data/nav/footer.toml":		{Data: []byte("Links = [\"Privacy\"]\n")},
+		"data/people.csv":			{Data: []byte("name,role\nAda,admin\n\"Bob, Jr\",editor\n")},
+		"data/notes.txt":			{Data: []byte("ignored")},
+		"templates/index.html":		{Data: []byte(`{{ .Data.site.Name }} {{ .Data.site.Year }}|{{ range .Data.nav.main }}{{ . }} {{ end }}|{{ range .Data.people }}{{ .name }}={{ .role }};{{ end }}`)},
+	}
+
+	tm := Init("templates", ".html").DataDirectory("data/")
+	tm.fileSystem = http.FS(files)
+
+	buf := &bytes.Buffer{}
+	err := tm.Render("index.html", nil, buf)
+	if err != nil {
+		tester.Fatalf("\033[31mFAIL: \033[36mRender\033[0m: %s", err.Error())
+	}
+
+	tests := []struct { inputs []any; result any; expected any }{
+		{[]any{"index.html"}, buf.String(), "Site 2024|Home About |Ada=admin;Bob, Jr=editor;"},
+		{[]any{"nav/footer.toml"}, tm.data["nav"], map[string]any{"main": []string{"Home", "About"}, "footer": map[string][]string{"Links": {"Privacy"}}}},
+		{[]any{"notes.txt"}, tm.data["notes"], nil},
+	}
+
+	parseError := func(files fstest.MapFS) string {
+		tm := Init("templates", ".html").DataDirectory("data")
+		tm.fileSystem = http.FS(files)
+		if err := tm.Parse(); err != nil {
+			return err.Error()
+		}
+		return ""
+	}
+
+	tests = append(tests, []struct { inputs []any; result any; expected any }{
+		{[]any{"missing directory"}, parseError(fstest.MapFS{"templates/index.html": {Data: []byte(`{{ .Data }}`)}}), ""},
+		{[]any{"invalid csv"}, parseError(fstest.MapFS{"data/bad.csv": {Data: []byte("a,b\n1\n")}}), "data/bad.csv: invalid csv data: record on line 2: wrong number of fields"},
+		{[]any{"duplicate names"}, parseError(fstest.MapFS{"data/site.json": {Data: []byte(`{}`)}, "data/site.yaml": {Data: []byte(`a: 1`)}}), `data/site.yaml: data "site" is already defined by another file or directory`},
+	}...)
+
+	testRunTests("loadData", tests, tester)
+}
+
+func TestDirectoryParams(tester *testing.T) {
+	files := fstest.MapFS{
+		"templates/_params.json":			{Data: []byte(`{"Section": "root", "Theme": "light"}`)},
+		"templates/blog/_params.json":		{Data: []byte(`{"Section": "blog"}`)},
+		"templates/blog/2024/post.html":	{Data: []byte(`{{ .Site }}|{{ .Section }}|{{ .Theme }}|{{ .Author }}|{{ .Year }}`)},
+		"templates/index.html":				{Data: []byte(`{{ .Site }}|{{ .Section }}|{{ .Theme }}|{{ .Author }}|{{ .Year }}`)},
+	}
+
+	tm := Init("templates", ".html").Reload(true)
+	tm.fileSystem = http.FS(files)
+	tm.AddGlobalParams(Params{"Site": "global", "Theme": "global"})
+	tm.AddDirectoryParam("/blog/", "Author", "blog")
+	tm.AddDirectoryParams("blog/2024", Params{"Year": 2024, "Section": "code"})
+
+	render := func(name string) string {
+		buf := &bytes.Buffer{}
+		err := tm.Render(name, nil, buf)
+		if err != nil {
+			return err.Error()
+		}
+		return buf.String()
+	}
+
+	tests := []struct { inputs []any; result any; expected any }{
+		{[]any{"index.html"}, render("index.html"), "global|root|light|<no value>|<no value>"},
+		{[]any{"blog/2024/post.html"}, render("blog/2024/post.html"), "global|code|light|blog|2024"},
+	}
+
+	files["templates/blog/_params.json"] = &fstest.MapFile{Data: []byte(`{"Theme": "dark"}`)}
+	tests = append(tests, struct { inputs []any; result any; expected any }{[]any{"blog/2024/post.html", "reloaded"}, render("blog/2024/post.html"), "global|code|dark|blog|2024"})
+
+	invalid := Init("templates", ".html")
+	invalid.fileSystem = http.FS(fstest.MapFS{"templates/_params.json": {Data: []byte(`{"Section": }`)}})
+	err := invalid.Parse()
+	tests = append(tests, struct { inputs []any; result any; expected any }{[]any{"invalid _params.json"}, err != nil && strings.HasPrefix(err.Error(), "templates/_params.json: invalid params: "), true})
+
+	testRunTests("directoryParams", tests, tester)
+}
+
+func TestRenderRequest(tester *testing.T) {
+	files := fstest.MapFS{
+		"templates/index.html": {Data: []byte(`{{ var "User" }} var {{ end }}{{ .Path }}|{{ .User }}|{{ .Token }}|{{ .Title }}`)},
+	}
+
+	tm := Init("templates", ".html")
+	tm.fileSystem = http.FS(files)
+	tm.AddGlobalParam("Token", "global")
+	tm.AddContextProcessor(func(request *http.Request) Params {
+		return Params{"Path": request.URL.Path, "User": request.Header.Get("X-User"), "Token": "first"}
+	})
+	tm.AddContextProcessors([]func(*http.Request) Params{
+		func(request *http.Request) Params { return Params{"Token": "second", "Title": "request"} },
+	})
+
+	render := func(data any) string {
+		request := httptest.NewRequest("GET", "/about", nil)
+		request.Header.Set("X-User", "ada")
+
+		buf := &bytes.Buffer{}
+		err := tm.RenderRequest(buf, request, "index.html", data)
+		if err != nil {
+			return err.Error()
+		}
+		return buf.String()
+	}
+
+	plain := &bytes.Buffer{}
+	tm.Render("index.html", Params{"Title": "render"}, plain)
+
+	tests := []struct { inputs []any; result any; expected any }{
+		{[]any{"index.html", nil}, render(nil), "/about|ada|second|request"},
+		{[]any{"index.html", Params{"Title": "render"}}, render(Params{"Title": "render"}), "/about|ada|second|render"},
+		{[]any{"index.html", "Render"}, plain.String(), "<no value>|var|global|render"},
+	}
+
+	testRunTests("RenderRequest", tests, tester)
+}
+
+type testRenderBase struct {
+	Site	string
+}
+
+type testRenderData struct {
+	testRenderBase
+	Title	string
+	Tags	[]string
+	hidden	string
+}
+
+func TestRenderData(tester *testing.T) {
+	files := fstest.MapFS{
+		"templates/layouts/main.html":	{Data: []byte(`{{ var "Scripts" }} ["main.js"] {{ end }}{{ block "content" . }}{{ end }}`)},
+		"templates/index.html":			{Data: []byte(`{{ extends "layouts/main.html" }}{{ var "Scripts" append }} ["page.js"] {{ end }}{{ define "content" }}{{ .Site }}|{{ .Title }}|{{ range .Tags }}{{ . }}{{ end }}|{{ range .Scripts }}{{ . }} {{ end }}{{ end }}`)},
+	}
+
+	tm := Init("templates", ".html")
+	tm.fileSystem = http.FS(files)
+
+	render := func(data any) string {
+		buf := &bytes.Buffer{}
+		err := tm.Render("index.html", data, buf)
+		if err != nil {
+			return err.Error()
+		}
+		return buf.String()
+	}
+
+	dataError := func(data any) string {
+		if _, err := dataToParams(data); err != nil {
+			return err.Error()
+		}
+		return ""
+	}
+
+	tags		:= make([]string, 1, 4)
+	tags[0]		= "go"
+	params		:= Params{"Title": "params", "Tags": tags}
+	structure	:= testRenderData{testRenderBase{"site"}, "struct", tags, "hidden"}
+
+	tests := []struct { inputs []any; result any; expected any }{
+		{[]any{"Params"}, render(params), "<no value>|params|go|main.js page.js "},
+		{[]any{"map[string]string"}, render(map[string]string{"Title": "map"}), "<no value>|map||main.js page.js "},
+		{[]any{"struct"}, render(structure), "site|struct|go|main.js page.js "},
+		{[]any{"*struct"}, render(&structure), "site|struct|go|main.js page.js "},
+		{[]any{"nil *struct"}, render((*testRenderData)(nil)), "<no value>|<no value>||main.js page.js "},
+		{[]any{"map[int]string"}, dataError(map[int]string{1: "a"}), "cannot render with data of type map[int]string: map keys must be strings"},
+		{[]any{"int"}, dataError(1), "cannot render with data of type int: must be a map or a struct"},
+		{[]any{"unmodified Params"}, params, Params{"Title": "params", "Tags": []string{"go"}}},
+		{[]any{"unmodified slice"}, tags[:cap(tags)], []string{"go", "", "", ""}},
+		{[]any{"unmodified vars"}, tm.vars["layouts/main.html"]["Scripts"].value, []string{"main.js"}},
+	}
+
+	testRunTests("Render", tests, tester)
+}
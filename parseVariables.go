@@ -5,10 +5,31 @@ Functions dedicated to the parsing of variables embedded in templates (as string
 */
 
 import (
-	"strings"
+	"fmt"
+	"reflect"
 	"strconv"
+	"strings"
+	"unicode"
 )
 
+// A syntax error found whilst parsing a variable literal (line and column are relative to the literal itself)
+type literalSyntaxError struct {
+	Line	int
+	Column	int
+	Message	string
+}
+
+func (e *literalSyntaxError) Error() string {
+	return fmt.Sprintf("line %d, column %d: %s", e.Line, e.Column, e.Message)
+}
+
+// A recursive descent parser for variable literals.
+// The grammar is close to JSON, but strings may use any of the ", ' or ` quotes and booleans are case-insensitive.
+type literalParser struct {
+	input	[]rune
+	pos		int
+}
+
 // Determines a variable's (likely) basic type from a string representation of it
 func getVariableType(value string, b ...string) (string, any) {
 	bias := ""
@@ -27,247 +48,353 @@ func getVariableType(value string, b ...string) (string, any) {
 	} else if val, err := strconv.ParseBool(strings.ToLower(value)); err == nil {
 		return "bool", val
 	}
-	
+
 	return "string", value
 }
 
-// Generic helper to parse supported map type combinations from a map of strings to a map of their actual types
-func parseVariableMap[K string|int|float64|bool, V string|int|float64|bool](values map[string]string) map[K]V {
-	tmp := map[K]V{}
-	for key, val := range values {
-		_, key := getVariableType(key)
-		_, val := getVariableType(val)
-		tmp[key.(K)] = val.(V)
+// Parses the string representation of a variable into its actual type.
+// Maps and slices may be nested to any depth, and are strongly typed (e.g. `[]int`, `map[string][]string`) when all
+// of their values share a type, falling back to `[]any` / `map[K]any` when they do not.
+// Anything that does not start like a map or slice is treated as a single scalar value (so unquoted text is a string).
+func parseVariableLiteral(value string) (any, error) {
+	value = strings.TrimSpace(value)
+
+	if !strings.HasPrefix(value, "{") && !strings.HasPrefix(value, "[") {
+		_, val := getVariableType(value)
+		return val, nil
 	}
 
-	return tmp
-}
+	parser := &literalParser{input: []rune(value)}
 
-// Generic helper to parse supported slice types from a slice of strings to a slice of their actual type
-func parseVariableSlice[V string|int|float64|bool](values []string) []V {
-	t := ""
-	var emptyV V
-	var emptyVInterface any = emptyV
-	if _, ok := emptyVInterface.(int); ok {
-		t = "int"
-	} else if _, ok := emptyVInterface.(float64); ok {
-		t = "float"
+	result, err := parser.parseValue()
+	if err != nil {
+		return nil, err
 	}
-	
-	tmp := []V{}
-	for _, val := range values {
-		_, v := getVariableType(val, t)
-		tmp = append(tmp, v.(V))
+
+	parser.skipWhitespace()
+	if parser.pos < len(parser.input) {
+		return nil, parser.error("unexpected %q after the end of the value", parser.input[parser.pos])
 	}
 
-	return tmp
+	return result, nil
 }
 
-// Generic helper to parse supported nested slice types from a slice of strings to a slice of their actual type
-func parseNestedVariableSlice[V string|int|float64|bool](values []string) [][]V {
-	t := ""
-	var emptyV V
-	var emptyVInterface any = emptyV
-	if _, ok := emptyVInterface.(int); ok {
-		t = "int"
-	} else if _, ok := emptyVInterface.(float64); ok {
-		t = "float"
-	}
+// Parses any value at the current position
+func (p *literalParser) parseValue() (any, error) {
+	p.skipWhitespace()
 
-	tmp := [][]V{}
-	for _, val := range values {
-		sub := []V{}
-		tmp2 := prepareSlice(val)
+	if p.pos >= len(p.input) {
+		return nil, p.error("unexpected end of input, expected a value")
+	}
 
-		for _, subval := range tmp2 {
-			_, v := getVariableType(subval, t)
-			sub = append(sub, v.(V))
-		}
-		tmp = append(tmp, sub)
+	switch r := p.input[p.pos]; {
+		case r == '{':
+			return p.parseMap()
+		case r == '[':
+			return p.parseSlice()
+		case r == '"' || r == '\'' || r == '`':
+			return p.parseString()
+		case r == '-' || r == '+' || r == '.' || unicode.IsDigit(r):
+			return p.parseNumber()
+		case unicode.IsLetter(r):
+			return p.parseWord()
 	}
 
-	return tmp
+	return nil, p.error("unexpected %q, expected a value", p.input[p.pos])
 }
 
-// Parses a string representation of a map into string values ready for type detection
-func prepareMap(value string) map[string]string {
-	value = value[1:len(value) - 1]
-	value = value + ","
-	m := make(map[string]string)
+// Parses a map: `{key: value, ...}` (keys must be scalar values)
+func (p *literalParser) parseMap() (any, error) {
+	p.pos++ // {
+	keys, values, starts := []any{}, []any{}, []int{}
 
-	if regexps["findStringStringMap"].MatchString(value) {
-		matches := regexps["findStringStringMap"].FindAllStringSubmatch(value, -1)
-		for _, match := range matches {
-			m[match[1]] = match[2]
+	for {
+		p.skipWhitespace()
+		if p.consume('}') {
+			break
 		}
 
-		return m
-	}
+		start := p.pos
+		key, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
 
-	if regexps["findStringNumericMap"].MatchString(value) {
-		matches := regexps["findStringNumericMap"].FindAllStringSubmatch(value, -1)
-		for _, match := range matches {
-			m[match[1]] = match[2]
+		switch key.(type) {
+			case string, int, float64, bool:
+			default:
+				p.pos = start
+				return nil, p.error("map keys must be strings, numbers or booleans")
 		}
 
-		return m
-	}
+		p.skipWhitespace()
+		if !p.consume(':') {
+			return nil, p.expected("':' after map key")
+		}
 
-	if regexps["findStringBoolMap"].MatchString(value) {
-		matches := regexps["findStringBoolMap"].FindAllStringSubmatch(value, -1)
-		for _, match := range matches {
-			m[match[1]] = strings.ToLower(match[2])
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
 		}
 
-		return m
-	}
+		keys	= append(keys, key)
+		values	= append(values, value)
+		starts	= append(starts, start)
 
-	if regexps["findNumericStringMap"].MatchString(value) {
-		matches := regexps["findNumericStringMap"].FindAllStringSubmatch(value, -1)
-		for _, match := range matches {
-			m[match[1]] = match[2]
+		p.skipWhitespace()
+		if p.consume(',') {
+			continue
+		}
+		if p.consume('}') {
+			break
 		}
 
-		return m
+		return nil, p.expected("',' or '}' in map")
 	}
 
-	if regexps["findNumericNumericMap"].MatchString(value) {
-		matches := regexps["findNumericNumericMap"].FindAllStringSubmatch(value, -1)
-		for _, match := range matches {
-			m[match[1]] = match[2]
+	// Keys are compared once converted to their shared type, so `1` and `1.0` are the same key
+	keyType := commonLiteralType(keys)
+	seen := map[any]bool{}
+	for i, key := range keys {
+		converted := key
+		if keyType != nil {
+			converted = convertLiteral(key, keyType).Interface()
 		}
 
-		return m
+		if seen[converted] {
+			p.pos = starts[i]
+			return nil, p.error("duplicate map key %v", key)
+		}
+		seen[converted] = true
 	}
 
-	if regexps["findNumericBoolMap"].MatchString(value) {
-		matches := regexps["findNumericBoolMap"].FindAllStringSubmatch(value, -1)
-		for _, match := range matches {
-			m[match[1]] = strings.ToLower(match[2])
+	return typedLiteralMap(keys, values), nil
+}
+
+// Parses a slice: `[value, ...]`
+func (p *literalParser) parseSlice() (any, error) {
+	p.pos++ // [
+	values := []any{}
+
+	for {
+		p.skipWhitespace()
+		if p.consume(']') {
+			break
 		}
 
-		return m
-	}
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
 
-	if regexps["findBoolStringMap"].MatchString(value) {
-		matches := regexps["findBoolStringMap"].FindAllStringSubmatch(value, -1)
-		for _, match := range matches {
-			m[strings.ToLower(match[1])] = match[2]
+		p.skipWhitespace()
+		if p.consume(',') {
+			continue
+		}
+		if p.consume(']') {
+			break
 		}
 
-		return m
+		return nil, p.expected("',' or ']' in slice")
 	}
 
-	if regexps["findBoolNumericMap"].MatchString(value) {
-		matches := regexps["findBoolNumericMap"].FindAllStringSubmatch(value, -1)
-		for _, match := range matches {
-			m[strings.ToLower(match[1])] = match[2]
+	return typedLiteralSlice(values), nil
+}
+
+// Parses a quoted string, which must be closed by the same quote that opened it
+func (p *literalParser) parseString() (any, error) {
+	quote	:= p.input[p.pos]
+	start	:= p.pos
+	p.pos++
+
+	var builder strings.Builder
+	for p.pos < len(p.input) {
+		r := p.input[p.pos]
+		p.pos++
+
+		if r == quote {
+			return builder.String(), nil
 		}
 
-		return m
+		if r == '\\' && p.pos < len(p.input) {
+			escaped := p.input[p.pos]
+			p.pos++
+
+			switch escaped {
+				case 'n': builder.WriteRune('\n')
+				case 't': builder.WriteRune('\t')
+				case 'r': builder.WriteRune('\r')
+				default: builder.WriteRune(escaped)
+			}
+			continue
+		}
+
+		builder.WriteRune(r)
 	}
 
-	if regexps["findBoolBoolMap"].MatchString(value) {
-		matches := regexps["findBoolBoolMap"].FindAllStringSubmatch(value, -1)
-		for _, match := range matches {
-			m[strings.ToLower(match[1])] = strings.ToLower(match[2])
-		}
+	p.pos = start
+	return nil, p.error("unterminated string, expected a closing %c", quote)
+}
 
-		return m
+// Parses an int (if possible) or a float
+func (p *literalParser) parseNumber() (any, error) {
+	start := p.pos
+	for p.pos < len(p.input) && strings.ContainsRune("+-.eE0123456789", p.input[p.pos]) {
+		p.pos++
 	}
+	number := string(p.input[start:p.pos])
 
-	/* 
-	if regexps["findSliceMap"].MatchString(value) {
-		matches := regexps["findSliceMap"].FindAllStringSubmatch(value, -1)
-		for _, match := range matches {
-			m[match[1]] = match[2] 
-		}
+	if val, err := strconv.Atoi(number); err == nil {
+		return val, nil
+	}
+
+	if val, err := strconv.ParseFloat(number, 64); err == nil {
+		return val, nil
 	}
-	*/
 
-	return m
+	p.pos = start
+	return nil, p.error("invalid number %q", number)
 }
 
-// Parses a string representation of a slice of booleans into a slice of strings ready for type detection
-func prepareBooleanSlice(value string) []string {
-	value = value + ","
-	slice := []string{}
+// Parses a bare word, which may only be a boolean (any case) or null
+func (p *literalParser) parseWord() (any, error) {
+	start := p.pos
+	for p.pos < len(p.input) && (unicode.IsLetter(p.input[p.pos]) || unicode.IsDigit(p.input[p.pos]) || p.input[p.pos] == '_') {
+		p.pos++
+	}
+	word := string(p.input[start:p.pos])
 
-	if regexps["findBooleanSlice"].MatchString(value) {
-		matches := regexps["findBooleanSlice"].FindAllStringSubmatch(value, -1)
-		for _, match := range matches {
-			slice = append(slice, strings.ToLower(match[1]))
-		}
+	switch strings.ToLower(word) {
+		case "true": return true, nil
+		case "false": return false, nil
+		case "null", "nil": return nil, nil
 	}
 
-	return slice
+	p.pos = start
+	return nil, p.error("unexpected %q, strings must be quoted", word)
 }
 
-// Parses a string representation of a slice of numbers into a slice of strings ready for type detection
-func prepareNumericSlice(value string) []string {
-	value = value + ","
-	slice := []string{}
-
-	if regexps["findNumericSlice"].MatchString(value) {
-		matches := regexps["findNumericSlice"].FindAllStringSubmatch(value, -1)
-		for _, match := range matches {
-			slice = append(slice, match[1])
-		}
+// Moves past the rune `r` if it is next in the input
+func (p *literalParser) consume(r rune) bool {
+	if p.pos < len(p.input) && p.input[p.pos] == r {
+		p.pos++
+		return true
 	}
 
-	return slice
+	return false
 }
 
-// Parses a string representation of a slice into a slice of string values ready for type detection
-func prepareSlice(value string) []string {
-	value = value[1:len(value) - 1]
-	var values = []string{}
-
-	if value[0:1] == "[" {
-		values = prepareSliceSlice(value)
-	} else if strings.Contains(value, `"`) || strings.Contains(value, `'`) || strings.Contains(value, "`") {
-		values = prepareStringSlice(value)
-	} else {
-		values = prepareNumericSlice(value)
+// Moves past any whitespace
+func (p *literalParser) skipWhitespace() {
+	for p.pos < len(p.input) && unicode.IsSpace(p.input[p.pos]) {
+		p.pos++
 	}
+}
 
-	if len(values) == 0 {
-		values = prepareBooleanSlice(value)
+// Creates an error describing what was expected at the current position
+func (p *literalParser) expected(what string) error {
+	if p.pos >= len(p.input) {
+		return p.error("unexpected end of input, expected %s", what)
 	}
 
-	return values
+	return p.error("unexpected %q, expected %s", p.input[p.pos], what)
 }
 
-// Parses a string representation of a slice of slices into a slice of strings ready for type detection
-func prepareSliceSlice(value string) []string {
-	value = value + ","
-	slice := []string{}
+// Creates an error at the current position
+func (p *literalParser) error(format string, a ...any) error {
+	line, column := 1, 1
+	for _, r := range p.input[:p.pos] {
+		if r == '\n' {
+			line++
+			column = 1
+		} else {
+			column++
+		}
+	}
+
+	return &literalSyntaxError{Line: line, Column: column, Message: fmt.Sprintf(format, a...)}
+}
+
+// Finds the type shared by all values: mixed ints and floats share float64, anything else mixed has no shared type
+func commonLiteralType(values []any) reflect.Type {
+	var common reflect.Type
+	numeric := true
 
-	if regexps["findSliceSlice"].MatchString(value) {
-		matches := regexps["findSliceSlice"].FindAllStringSubmatch(value, -1)
-		for _, match := range matches {
-			slice = append(slice, match[1])
+	for _, value := range values {
+		if value == nil {
+			return nil
+		}
+
+		typ := reflect.TypeOf(value)
+		numeric = numeric && (typ.Kind() == reflect.Int || typ.Kind() == reflect.Float64)
+
+		if common == nil {
+			common = typ
+		} else if common != typ {
+			if !numeric {
+				return nil
+			}
+			common = reflect.TypeOf(float64(0))
 		}
 	}
 
-	return slice
+	return common
 }
 
-// Parses a string representation of a slice of strings into an actual slice of strings ready for type detection
-func prepareStringSlice(value string) []string {
-	value = value + ","
-	slice := []string{}
+// Converts a value to the shared type found by `commonLiteralType`
+func convertLiteral(value any, typ reflect.Type) reflect.Value {
+	return reflect.ValueOf(value).Convert(typ)
+}
 
-	if regexps["findStringSlice"].MatchString(value) {
-		matches := regexps["findStringSlice"].FindAllStringSubmatch(value, -1)
-		for _, match := range matches {
-			val := strings.Replace(match[1], "\\`", "`", -1)
-			val = strings.Replace(val, `\"`, `"`, -1)
-			val = strings.Replace(val, "\\'", "'", -1)
+// Creates the most specific slice type possible for the values (empty slices are string slices)
+func typedLiteralSlice(values []any) any {
+	if len(values) == 0 {
+		return []string{}
+	}
 
-			slice = append(slice, val)
+	typ := commonLiteralType(values)
+	if typ == nil {
+		return values
+	}
+
+	slice := reflect.MakeSlice(reflect.SliceOf(typ), 0, len(values))
+	for _, value := range values {
+		slice = reflect.Append(slice, convertLiteral(value, typ))
+	}
+
+	return slice.Interface()
+}
+
+// Creates the most specific map type possible for the keys and values (empty maps are string maps)
+func typedLiteralMap(keys []any, values []any) any {
+	if len(keys) == 0 {
+		return map[string]string{}
+	}
+
+	keyType		:= commonLiteralType(keys)
+	valueType	:= commonLiteralType(values)
+
+	if keyType == nil {
+		keyType = reflect.TypeOf((*any)(nil)).Elem()
+	}
+
+	typed := valueType != nil
+	if !typed {
+		valueType = reflect.TypeOf((*any)(nil)).Elem()
+	}
+
+	m := reflect.MakeMapWithSize(reflect.MapOf(keyType, valueType), len(keys))
+	for i, key := range keys {
+		value := reflect.ValueOf(values[i])
+		if typed {
+			value = convertLiteral(values[i], valueType)
+		} else if values[i] == nil {
+			value = reflect.Zero(valueType)
 		}
+
+		m.SetMapIndex(convertLiteral(key, keyType), value)
 	}
 
-	return slice
-}
\ No newline at end of file
+	return m.Interface()
+}
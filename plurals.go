@@ -0,0 +1,444 @@
+package templateManager
+
+/*
+Functions dedicated to choosing plural forms, using the CLDR plural rules and gettext `Plural-Forms` expressions
+*/
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"golang.org/x/exp/slices"
+)
+
+// The CLDR plural categories, in their standard order
+var pluralCategories = []string{"zero", "one", "two", "few", "many", "other"}
+
+// The CLDR plural operands of a number: the absolute value (n), its integer digits (i), the number of visible
+// fraction digits (v) and the visible fraction digits with (f) and without (t) trailing zeros
+type pluralOperands struct {
+	n	float64
+	i	int64
+	v	int
+	f	int64
+	t	int64
+}
+
+// Finds the plural operands of any numeric value (or numeric string, which keeps its visible fraction digits)
+func newPluralOperands(count any) (pluralOperands, error) {
+	value	:= reflect.ValueOf(count)
+	text	:= ""
+
+	if value.Kind() == reflect.String {
+		text = strings.TrimSpace(value.String())
+		if _, err := strconv.ParseFloat(text, 64); err != nil {
+			return pluralOperands{}, fmt.Errorf("cannot choose a plural form for %q: not a number", text)
+		}
+	} else {
+		float, err := reflectHelperConvertToFloat64(value)
+		if err != nil {
+			return pluralOperands{}, fmt.Errorf("cannot choose a plural form for %v (%T): not a number", count, count)
+		}
+		text = strconv.FormatFloat(float, 'f', -1, 64)
+	}
+
+	text = strings.TrimLeft(text, "+-")
+	integer, fraction, _ := strings.Cut(text, ".")
+
+	operands := pluralOperands{v: len(fraction)}
+	operands.n, _ = strconv.ParseFloat(text, 64)
+	operands.i, _ = strconv.ParseInt(integer, 10, 64)
+	if len(fraction) > 0 {
+		operands.f, _ = strconv.ParseInt(fraction, 10, 64)
+		operands.t, _ = strconv.ParseInt("0" + strings.TrimRight(fraction, "0"), 10, 64)
+	}
+
+	return operands, nil
+}
+
+// Checks whether a (possibly fractional) value is a whole number between `from` and `to`
+func pluralInRange(value float64, from float64, to float64) bool {
+	return value == math.Trunc(value) && value >= from && value <= to
+}
+
+// Checks whether an integer is between `from` and `to`
+func pluralIntInRange(value int64, from int64, to int64) bool {
+	return value >= from && value <= to
+}
+
+// Chooses the CLDR plural category of a number for a locale's language. Languages that are not covered use the
+// English rules
+func pluralCategory(locale string, o pluralOperands) string {
+	language, _, _ := strings.Cut(normaliseLocale(locale), "-")
+
+	switch language {
+		case "ja", "zh", "ko", "th", "vi", "id", "ms", "lo", "my", "km", "yo":
+			return "other"
+		case "el", "hu", "tr", "bg", "az", "sq", "ka", "kk", "uz", "af", "eu", "ta", "te", "ml", "ne", "mn":
+			if o.n == 1 {
+				return "one"
+			}
+		case "fr":
+			if o.i == 0 || o.i == 1 {
+				return "one"
+			} else if o.i != 0 && o.i % 1000000 == 0 && o.v == 0 {
+				return "many"
+			}
+		case "pt":
+			if normaliseLocale(locale) == "pt-pt" {
+				if o.i == 1 && o.v == 0 {
+					return "one"
+				}
+			} else if o.i == 0 || o.i == 1 {
+				return "one"
+			}
+			if o.i != 0 && o.i % 1000000 == 0 && o.v == 0 {
+				return "many"
+			}
+		case "es":
+			if o.n == 1 {
+				return "one"
+			} else if o.i != 0 && o.i % 1000000 == 0 && o.v == 0 {
+				return "many"
+			}
+		case "it", "ca":
+			if o.i == 1 && o.v == 0 {
+				return "one"
+			} else if o.i != 0 && o.i % 1000000 == 0 && o.v == 0 {
+				return "many"
+			}
+		case "hi", "bn", "fa", "gu", "kn", "zu", "am":
+			if o.i == 0 || o.n == 1 {
+				return "one"
+			}
+		case "da":
+			if o.n == 1 || (o.t != 0 && (o.i == 0 || o.i == 1)) {
+				return "one"
+			}
+		case "ru", "uk":
+			if o.v == 0 && o.i % 10 == 1 && o.i % 100 != 11 {
+				return "one"
+			} else if o.v == 0 && pluralIntInRange(o.i % 10, 2, 4) && !pluralIntInRange(o.i % 100, 12, 14) {
+				return "few"
+			} else if o.v == 0 && (o.i % 10 == 0 || pluralIntInRange(o.i % 10, 5, 9) || pluralIntInRange(o.i % 100, 11, 14)) {
+				return "many"
+			}
+		case "pl":
+			if o.i == 1 && o.v == 0 {
+				return "one"
+			} else if o.v == 0 && pluralIntInRange(o.i % 10, 2, 4) && !pluralIntInRange(o.i % 100, 12, 14) {
+				return "few"
+			} else if o.v == 0 && ((o.i != 1 && pluralIntInRange(o.i % 10, 0, 1)) || pluralIntInRange(o.i % 10, 5, 9) || pluralIntInRange(o.i % 100, 12, 14)) {
+				return "many"
+			}
+		case "cs", "sk":
+			if o.i == 1 && o.v == 0 {
+				return "one"
+			} else if pluralIntInRange(o.i, 2, 4) && o.v == 0 {
+				return "few"
+			} else if o.v != 0 {
+				return "many"
+			}
+		case "hr", "sr", "bs":
+			if (o.v == 0 && o.i % 10 == 1 && o.i % 100 != 11) || (o.f % 10 == 1 && o.f % 100 != 11) {
+				return "one"
+			} else if (o.v == 0 && pluralIntInRange(o.i % 10, 2, 4) && !pluralIntInRange(o.i % 100, 12, 14)) || (pluralIntInRange(o.f % 10, 2, 4) && !pluralIntInRange(o.f % 100, 12, 14)) {
+				return "few"
+			}
+		case "sl":
+			if o.v == 0 && o.i % 100 == 1 {
+				return "one"
+			} else if o.v == 0 && o.i % 100 == 2 {
+				return "two"
+			} else if (o.v == 0 && pluralIntInRange(o.i % 100, 3, 4)) || o.v != 0 {
+				return "few"
+			}
+		case "ro":
+			if o.i == 1 && o.v == 0 {
+				return "one"
+			} else if o.v != 0 || o.n == 0 || (o.n != 1 && pluralInRange(math.Mod(o.n, 100), 1, 19)) {
+				return "few"
+			}
+		case "lt":
+			if math.Mod(o.n, 10) == 1 && !pluralInRange(math.Mod(o.n, 100), 11, 19) {
+				return "one"
+			} else if pluralInRange(math.Mod(o.n, 10), 2, 9) && !pluralInRange(math.Mod(o.n, 100), 11, 19) {
+				return "few"
+			} else if o.f != 0 {
+				return "many"
+			}
+		case "lv":
+			if math.Mod(o.n, 10) == 0 || pluralInRange(math.Mod(o.n, 100), 11, 19) || (o.v == 2 && pluralIntInRange(o.f % 100, 11, 19)) {
+				return "zero"
+			} else if (math.Mod(o.n, 10) == 1 && math.Mod(o.n, 100) != 11) || (o.v == 2 && o.f % 10 == 1 && o.f % 100 != 11) || (o.v != 2 && o.f % 10 == 1) {
+				return "one"
+			}
+		case "ar":
+			if o.n == 0 {
+				return "zero"
+			} else if o.n == 1 {
+				return "one"
+			} else if o.n == 2 {
+				return "two"
+			} else if pluralInRange(math.Mod(o.n, 100), 3, 10) {
+				return "few"
+			} else if pluralInRange(math.Mod(o.n, 100), 11, 99) {
+				return "many"
+			}
+		case "he":
+			if (o.i == 1 && o.v == 0) || (o.i == 0 && o.v != 0) {
+				return "one"
+			} else if o.i == 2 && o.v == 0 {
+				return "two"
+			}
+		case "ga":
+			if o.n == 1 {
+				return "one"
+			} else if o.n == 2 {
+				return "two"
+			} else if pluralInRange(o.n, 3, 6) {
+				return "few"
+			} else if pluralInRange(o.n, 7, 10) {
+				return "many"
+			}
+		case "cy":
+			switch o.n {
+				case 0: return "zero"
+				case 1: return "one"
+				case 2: return "two"
+				case 3: return "few"
+				case 6: return "many"
+			}
+		default:
+			if o.i == 1 && o.v == 0 {
+				return "one"
+			}
+	}
+
+	return "other"
+}
+
+// A compiled gettext `Plural-Forms` expression (e.g. `n%10==1 && n%100!=11 ? 0 : 1`), returning a form's index
+type pluralExpression func(n int64) int64
+
+// Parses the C-like expression used by the `plural=` part of a gettext `Plural-Forms` header
+func parsePluralExpression(expression string) (pluralExpression, error) {
+	parser := &pluralParser{}
+
+	for i := 0; i < len(expression); {
+		char := expression[i]
+		switch {
+			case char == ' ' || char == '\t':
+				i++
+			case char >= '0' && char <= '9':
+				j := i
+				for j < len(expression) && expression[j] >= '0' && expression[j] <= '9' {
+					j++
+				}
+				parser.tokens = append(parser.tokens, expression[i:j])
+				i = j
+			case i + 1 < len(expression) && slices.Contains([]string{"||", "&&", "==", "!=", "<=", ">="}, expression[i:i + 2]):
+				parser.tokens = append(parser.tokens, expression[i:i + 2])
+				i += 2
+			case strings.ContainsRune("n?:<>!%*/+-()", rune(char)):
+				parser.tokens = append(parser.tokens, string(char))
+				i++
+			default:
+				return nil, fmt.Errorf("invalid plural expression %q", expression)
+		}
+	}
+
+	result, err := parser.ternary()
+	if err == nil && parser.position < len(parser.tokens) {
+		err = fmt.Errorf("unexpected %q", parser.tokens[parser.position])
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid plural expression %q: %s", expression, err.Error())
+	}
+
+	return result, nil
+}
+
+// A recursive descent parser for plural expressions (following C's operator precedence)
+type pluralParser struct {
+	tokens		[]string
+	position	int
+}
+
+// Consumes the next token if it is one of `tokens`
+func (p *pluralParser) accept(tokens ...string) (string, bool) {
+	if p.position < len(p.tokens) {
+		for _, token := range tokens {
+			if p.tokens[p.position] == token {
+				p.position++
+				return token, true
+			}
+		}
+	}
+
+	return "", false
+}
+
+// condition ? a : b
+func (p *pluralParser) ternary() (pluralExpression, error) {
+	condition, err := p.binary(0)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, ok := p.accept("?"); !ok {
+		return condition, nil
+	}
+
+	yes, err := p.ternary()
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := p.accept(":"); !ok {
+		return nil, fmt.Errorf("missing \":\"")
+	}
+	no, err := p.ternary()
+	if err != nil {
+		return nil, err
+	}
+
+	return func(n int64) int64 {
+		if condition(n) != 0 {
+			return yes(n)
+		}
+		return no(n)
+	}, nil
+}
+
+// The binary operators, from the lowest precedence to the highest
+var pluralOperators = [][]string{{"||"}, {"&&"}, {"==", "!="}, {"<", "<=", ">", ">="}, {"+", "-"}, {"*", "/", "%"}}
+
+// Parses the binary operators with the given precedence (and all those above it)
+func (p *pluralParser) binary(level int) (pluralExpression, error) {
+	if level == len(pluralOperators) {
+		return p.unary()
+	}
+
+	left, err := p.binary(level + 1)
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		operator, ok := p.accept(pluralOperators[level]...)
+		if !ok {
+			return left, nil
+		}
+
+		right, err := p.binary(level + 1)
+		if err != nil {
+			return nil, err
+		}
+
+		left = pluralOperation(operator, left, right)
+	}
+}
+
+// Combines two expressions with a binary operator
+func pluralOperation(operator string, left pluralExpression, right pluralExpression) pluralExpression {
+	boolean := func(value bool) int64 {
+		if value {
+			return 1
+		}
+		return 0
+	}
+
+	return func(n int64) int64 {
+		a, b := left(n), right(n)
+		switch operator {
+			case "||": return boolean(a != 0 || b != 0)
+			case "&&": return boolean(a != 0 && b != 0)
+			case "==": return boolean(a == b)
+			case "!=": return boolean(a != b)
+			case "<": return boolean(a < b)
+			case "<=": return boolean(a <= b)
+			case ">": return boolean(a > b)
+			case ">=": return boolean(a >= b)
+			case "+": return a + b
+			case "-": return a - b
+			case "*": return a * b
+		}
+
+		if b == 0 {
+			return 0
+		} else if operator == "/" {
+			return a / b
+		}
+		return a % b
+	}
+}
+
+// !value, (expression), n or a number
+func (p *pluralParser) unary() (pluralExpression, error) {
+	if _, ok := p.accept("!"); ok {
+		value, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return func(n int64) int64 {
+			if value(n) == 0 {
+				return 1
+			}
+			return 0
+		}, nil
+	}
+
+	if _, ok := p.accept("("); ok {
+		value, err := p.ternary()
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := p.accept(")"); !ok {
+			return nil, fmt.Errorf("missing \")\"")
+		}
+		return value, nil
+	}
+
+	if _, ok := p.accept("n"); ok {
+		return func(n int64) int64 { return n }, nil
+	}
+
+	if p.position < len(p.tokens) {
+		if number, err := strconv.ParseInt(p.tokens[p.position], 10, 64); err == nil {
+			p.position++
+			return func(int64) int64 { return number }, nil
+		}
+		return nil, fmt.Errorf("unexpected %q", p.tokens[p.position])
+	}
+
+	return nil, fmt.Errorf("unexpected end")
+}
+
+// Lists the CLDR plural categories used by a locale's language (in their standard order), e.g. "one" and "other"
+// for English or "one", "few", "many" and "other" for Russian
+func PluralCategories(locale string) []string {
+	used := map[string]bool{}
+
+	for n := int64(0); n <= 1000; n++ {
+		used[pluralCategory(locale, pluralOperands{n: float64(n), i: n})] = true
+	}
+
+	for i := 0; i <= 20; i++ {
+		for _, fraction := range []string{"0", "1", "2", "5", "00", "01", "11"} {
+			operands, _ := newPluralOperands(fmt.Sprintf("%d.%s", i, fraction))
+			used[pluralCategory(locale, operands)] = true
+		}
+	}
+
+	categories := []string{}
+	for _, category := range pluralCategories {
+		if used[category] {
+			categories = append(categories, category)
+		}
+	}
+
+	return categories
+}
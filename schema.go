@@ -0,0 +1,292 @@
+package templateManager
+
+/*
+Functions dedicated to inferring the data that a template bundle uses by walking its parsed templates
+*/
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"text/template/parse"
+
+	"golang.org/x/exp/slices"
+)
+
+// Describes the data used by an entry template, as returned by `Schema()`
+type Schema struct {
+	Template	string			`json:"template"`
+	Fields		[]*SchemaField	`json:"fields"`
+}
+
+// A single field used by a template. Fields used with `range` are collections, and their `Fields` describe each item
+type SchemaField struct {
+	Name		string			`json:"name"`
+	Collection	bool			`json:"collection,omitempty"`
+	Provided	bool			`json:"provided,omitempty"`
+	Fields		[]*SchemaField	`json:"fields,omitempty"`
+}
+
+// Lists every field path in the schema (e.g. "Title", "User.Name", "Items[].Url")
+func (s *Schema) Paths() []string {
+	paths := []string{}
+	for _, field := range s.Fields {
+		paths = append(paths, field.paths("")...)
+	}
+
+	return paths
+}
+
+// Lists the top level fields that must be passed to `Render()` (those not provided by vars or other params)
+func (s *Schema) Required() []string {
+	required := []string{}
+	for _, field := range s.Fields {
+		if !field.Provided {
+			required = append(required, field.Name)
+		}
+	}
+
+	return required
+}
+
+// Finds a top level field by name (nil if the template does not use it)
+func (s *Schema) Field(name string) *SchemaField {
+	for _, field := range s.Fields {
+		if field.Name == name {
+			return field
+		}
+	}
+
+	return nil
+}
+
+// Lists the paths of a field and all of its nested fields
+func (f *SchemaField) paths(prefix string) []string {
+	path := prefix + f.Name
+	if f.Collection {
+		path += "[]"
+	}
+
+	paths := []string{path}
+	for _, field := range f.Fields {
+		paths = append(paths, field.paths(path + ".")...)
+	}
+
+	return paths
+}
+
+// Finds (or adds) a nested field
+func (f *SchemaField) child(name string) *SchemaField {
+	for _, field := range f.Fields {
+		if field.Name == name {
+			return field
+		}
+	}
+
+	field := &SchemaField{Name: name}
+	f.Fields = append(f.Fields, field)
+
+	return field
+}
+
+// Sorts the nested fields (recursively) by name
+func (f *SchemaField) sort() {
+	sort.Slice(f.Fields, func(i, j int) bool { return f.Fields[i].Name < f.Fields[j].Name })
+	for _, field := range f.Fields {
+		field.sort()
+	}
+}
+
+// Walks the parse trees of a bundle, following the value of dot through `range`, `with` and `template`
+type schemaWalker struct {
+	trees	map[string]*parse.Tree
+	root	*SchemaField
+	visited	map[string][]*SchemaField
+}
+
+// The variables ($name) known at a point in a template, mapped to the field that they hold (nil if unknown)
+type schemaScope map[string]*SchemaField
+
+// Builds the schema of the `name` entry template from its bundle (and the pipelines of any `eval` vars it uses)
+func (tm *TemplateManager) buildSchema(name string) (*Schema, error) {
+	tmpl, ok := tm.templates[name]
+	if !ok {
+		return nil, fmt.Errorf("template %s not found", name)
+	}
+
+	walker := &schemaWalker{trees: tmpl.Trees(), root: &SchemaField{}, visited: map[string][]*SchemaField{}}
+	walker.walkTemplate(name, walker.root)
+
+	provided := map[string]bool{}
+	for _, layer := range tm.paramLayers(name, nil, nil) {
+		for key, value := range layer.params {
+			provided[key] = true
+
+			if variable, ok := value.(evalVar); ok {
+				for _, tree := range variable.template.Trees() {
+					walker.walkNode(tree.Root, walker.root, schemaScope{"$": walker.root})
+				}
+			}
+		}
+	}
+
+	walker.root.sort()
+	for _, field := range walker.root.Fields {
+		field.Provided = provided[field.Name]
+	}
+
+	return &Schema{Template: name, Fields: walker.root.Fields}, nil
+}
+
+// Walks a named template with dot set to `dot` (each template is only walked once for each value of dot)
+func (w *schemaWalker) walkTemplate(name string, dot *SchemaField) {
+	tree, ok := w.trees[name]
+	if !ok || tree.Root == nil || slices.Contains(w.visited[name], dot) {
+		return
+	}
+	w.visited[name] = append(w.visited[name], dot)
+
+	w.walkNode(tree.Root, dot, schemaScope{"$": dot})
+}
+
+// Walks a single node, recording every field used relative to `dot` (nil when the value of dot is unknown)
+func (w *schemaWalker) walkNode(node parse.Node, dot *SchemaField, scope schemaScope) {
+	switch typed := node.(type) {
+		case *parse.ListNode:
+			if typed == nil {
+				return
+			}
+			for _, child := range typed.Nodes {
+				w.walkNode(child, dot, scope)
+			}
+		case *parse.ActionNode:
+			w.walkPipe(typed.Pipe, dot, scope)
+		case *parse.IfNode:
+			w.walkPipe(typed.Pipe, dot, scope)
+			w.walkNode(typed.List, dot, scope.copy())
+			w.walkNode(typed.ElseList, dot, scope.copy())
+		case *parse.WithNode:
+			inner := scope.copy()
+			value := w.walkPipe(typed.Pipe, dot, inner)
+			w.walkNode(typed.List, value, inner)
+			w.walkNode(typed.ElseList, dot, scope.copy())
+		case *parse.RangeNode:
+			inner := scope.copy()
+			value := w.walkPipe(typed.Pipe, dot, inner)
+
+			var item *SchemaField
+			if value != nil {
+				value.Collection = true
+				item = value
+			}
+
+			// `range $index, $item := ...` declares the item as the last variable
+			if count := len(typed.Pipe.Decl); count > 0 {
+				if count > 1 {
+					inner[typed.Pipe.Decl[0].Ident[0]] = nil
+				}
+				inner[typed.Pipe.Decl[count - 1].Ident[0]] = item
+			}
+
+			w.walkNode(typed.List, item, inner)
+			w.walkNode(typed.ElseList, dot, scope.copy())
+		case *parse.TemplateNode:
+			var value *SchemaField
+			if typed.Pipe != nil {
+				value = w.walkPipe(typed.Pipe, dot, scope)
+			}
+			if value != nil {
+				w.walkTemplate(typed.Name, value)
+			}
+	}
+}
+
+// Walks a pipeline, returning the field that it evaluates to (nil if it is not simply a field, dot or variable)
+func (w *schemaWalker) walkPipe(pipe *parse.PipeNode, dot *SchemaField, scope schemaScope) *SchemaField {
+	if pipe == nil {
+		return nil
+	}
+
+	var result *SchemaField
+	for i, command := range pipe.Cmds {
+		// Uses of view methods are rewritten as `viewMethod <receiver> "Name"` once the view has been checked
+		if receiver, name, ok := viewMethodCommand(command); ok {
+			value := schemaPath(w.walkArgument(receiver, dot, scope), []string{name})
+			if len(pipe.Cmds) == 1 {
+				result = value
+			}
+			continue
+		}
+
+		for _, argument := range command.Args {
+			value := w.walkArgument(argument, dot, scope)
+			if i == 0 && len(pipe.Cmds) == 1 && len(command.Args) == 1 {
+				result = value
+			}
+		}
+	}
+
+	for _, variable := range pipe.Decl {
+		scope[variable.Ident[0]] = result
+	}
+
+	return result
+}
+
+// Walks a single argument of a command, returning the field that it refers to (if any)
+func (w *schemaWalker) walkArgument(node parse.Node, dot *SchemaField, scope schemaScope) *SchemaField {
+	switch typed := node.(type) {
+		case *parse.DotNode:
+			return dot
+		case *parse.FieldNode:
+			return schemaPath(dot, typed.Ident)
+		case *parse.VariableNode:
+			return schemaPath(scope[typed.Ident[0]], typed.Ident[1:])
+		case *parse.ChainNode:
+			return schemaPath(w.walkArgument(typed.Node, dot, scope), typed.Field)
+		case *parse.PipeNode:
+			return w.walkPipe(typed, dot, scope)
+	}
+
+	return nil
+}
+
+// Records a path of fields below `field` (if it is known), returning the last one
+func schemaPath(field *SchemaField, names []string) *SchemaField {
+	if field == nil {
+		return nil
+	}
+
+	for _, name := range names {
+		field = field.child(name)
+	}
+
+	return field
+}
+
+// Copies the variables in a scope so that those declared within a control structure do not leak out of it
+func (s schemaScope) copy() schemaScope {
+	scope := schemaScope{}
+	for name, field := range s {
+		scope[name] = field
+	}
+
+	return scope
+}
+
+// Lists the top level fields of a schema that are missing from `params`
+func missingSchemaFields(schema *Schema, params Params) []string {
+	missing := []string{}
+	for _, field := range schema.Fields {
+		if _, ok := params[field.Name]; !ok {
+			missing = append(missing, field.Name)
+		}
+	}
+
+	return missing
+}
+
+// Formats the missing fields of a schema for an error or warning
+func missingSchemaFieldsError(name string, missing []string) string {
+	return fmt.Sprintf("template %s uses params that were not set: %s", name, strings.Join(missing, ", "))
+}
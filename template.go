@@ -5,6 +5,7 @@ import (
 	"io"
 	TT "text/template"
 	HT "html/template"
+	"text/template/parse"
 )
 
 func NewTemplate(typ string, name string) *Template {
@@ -41,6 +42,16 @@ func (t *Template) InitHtml(name string) *HT.Template {
 	t.text = nil
 	return t.html
 }
+func (t *Template) Clone() (*Template, error) {
+	if t.Type() == "text" {
+		template, err := t.text.Clone()
+		return &Template{ nil, template }, err
+	} else if t.Type() == "html" {
+		template, err := t.html.Clone()
+		return &Template{ template, nil }, err
+	}
+	return nil, fmt.Errorf("templateManager Template not loaded correctly")
+}
 func (t *Template) Delims(left string, right string) *Template {
 	if t.Type() == "text" {
 		t.text.Delims(left, right)
@@ -118,6 +129,23 @@ func (t *Template) Parse(text string) (*Template, error) {
 	}
 	return t, err
 }
+func (t *Template) Trees() map[string]*parse.Tree {
+	trees := map[string]*parse.Tree{}
+	if t.Type() == "text" {
+		for _, template := range t.text.Templates() {
+			if template.Tree != nil {
+				trees[template.Name()] = template.Tree
+			}
+		}
+	} else if t.Type() == "html" {
+		for _, template := range t.html.Templates() {
+			if template.Tree != nil {
+				trees[template.Name()] = template.Tree
+			}
+		}
+	}
+	return trees
+}
 func (t *Template) Type() string {
 	if t.text == nil && t.html == nil {
 		return "invalid"
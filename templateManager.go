@@ -25,17 +25,21 @@ package templateManager
 import (
 	"bytes"
 	"embed"
+	"errors"
 	"fmt"
 	"io"
 	"io/fs"
 	"net/http"
 	"os"
+	"path"
 	"path/filepath"
+	"reflect"
 	"regexp"
 	"sort"
 	"strings"
 	"strconv"
 	"sync"
+	"unicode"
 
 	"golang.org/x/exp/slices"
 	"github.com/google/uuid"
@@ -48,9 +52,27 @@ type TemplateManager struct {
 	templateType			string
 	templates 				map[string]*Template
 	params					map[string]map[string]any
+	vars					map[string]map[string]templateVar
+	meta					map[string]Params
+	schemas					map[string]*Schema
+	globalParams			Params
+	directoryParams			map[string]Params
+	directoryFileParams		map[string]Params
 	descendants				map[string][]string
 	componentDirectories	[]string
 	components				map[string]string
+	ambiguousComponents		map[string][]string
+	componentInherits		[]string
+	kebabComponents			bool
+	dataDirectory			string
+	data					map[string]any
+	translationsDirectory	string
+	translations			map[string]translationCatalog
+	defaultLocale			string
+	locales					[]string
+	localeSources			map[string]*Template
+	localeTemplates			map[string]map[string]*Template
+	localeMutex				sync.Mutex
 	delimiterLeft			string
 	delimiterRight			string
 	fileSystem				http.FileSystem
@@ -58,7 +80,10 @@ type TemplateManager struct {
 	extensions				[]string
 	excludedDirectories		[]string
 	functions				map[string]any
+	contextProcessors		[]func(*http.Request) Params
+	views					[]*registeredView
 	missingKey				string
+	strict					string
 	mutex					sync.RWMutex
 	debug					bool
 	reload					bool
@@ -68,25 +93,59 @@ type TemplateManager struct {
 // Convenience type allowing any variables types to be passed in
 type Params map[string]any
 
+// The file which holds the variables shared by all templates in its directory
+const directoryParamsFile = "_params.json"
+
+// The key that components are passed the page's parameters under (not a valid field name, so it can't be used in templates)
+const componentRootKey = "tm-component-root"
+
+// Carries the parameters given to the page through (possibly nested) components without exposing them
+type componentRootCarrier struct {
+	params any
+}
+
 // Allow regexps to be pre-compiled
 var regexps map[string]*regexp.Regexp
 
+// Marks component usage and asset placement in rendered output (replaced before anything is written)
+var componentAssetMarker = "tm-component-" + strings.ReplaceAll(uuid.NewString(), "-", "")
+
 // Creates a new `TemplateManager` struct instance
 func Init(directory string, extensions ...string) *TemplateManager {
 	templateManager := &TemplateManager{
 		templateType:			"text",
 		templates:				make(map[string]*Template),
 		params:					make(map[string]map[string]any),
+		vars:					make(map[string]map[string]templateVar),
+		meta:					make(map[string]Params),
+		schemas:				make(map[string]*Schema),
+		globalParams:			make(Params),
+		directoryParams:		make(map[string]Params),
+		directoryFileParams:	make(map[string]Params),
 		descendants:			make(map[string][]string),
 		componentDirectories:	[]string{"components"},
 		components:				make(map[string]string),
+		ambiguousComponents:	make(map[string][]string),
+		componentInherits:		[]string{},
+		kebabComponents:		false,
+		dataDirectory:			"",
+		data:					make(map[string]any),
+		translationsDirectory:	"",
+		translations:			make(map[string]translationCatalog),
+		defaultLocale:			"en",
+		locales:				[]string{},
+		localeSources:			make(map[string]*Template),
+		localeTemplates:		make(map[string]map[string]*Template),
 		delimiterLeft:			"{{",
 		delimiterRight:			"}}",
 		directory:				directory,
 		extensions:				extensions,
 		excludedDirectories:	[]string{"layouts", "partials", "components"},
 		functions:				make(map[string]any),
+		contextProcessors:		[]func(*http.Request) Params{},
+		views:					[]*registeredView{},
 		missingKey:				"zero",
+		strict:					"off",
 		debug:					false,
 		reload:					false,
 		parsed:					false,
@@ -203,6 +262,28 @@ func (tm *TemplateManager) AddComponentDirectory(directory string) *TemplateMana
 	return tm
 }
 
+// Passes the variable `name` from the caller's data into every component (as if it had been set as an attribute).
+// The special name "Root" passes the complete set of parameters given to the page as `.Root`.
+func (tm *TemplateManager) InheritComponentParam(name string) *TemplateManager {
+	if !slices.Contains(tm.componentInherits, name) {
+		tm.mutex.Lock()
+		tm.componentInherits = append(tm.componentInherits, name)
+		tm.mutex.Unlock()
+	}
+
+	return tm
+}
+
+// Passes the variables named from the caller's data into every component (as if they had been set as attributes).
+// The special name "Root" passes the complete set of parameters given to the page as `.Root`.
+func (tm *TemplateManager) InheritComponentParams(names []string) *TemplateManager {
+	for _, name := range names {
+		tm.InheritComponentParam(name)
+	}
+
+	return tm
+}
+
 // Adds a single variable (`name`) with value `value` that will always be available in the `templateName` template
 func (tm *TemplateManager) AddParam(templateName string, name string, value any) *TemplateManager {
 	if _, ok := tm.params[templateName]; !ok {
@@ -223,6 +304,93 @@ func (tm *TemplateManager) AddParams(templateName string, params Params) *Templa
 	return tm
 }
 
+// Adds a processor which creates variables from the current request for every template rendered with `RenderRequest()`
+// (e.g. the current URL, user or CSRF token). Later processors take precedence over earlier ones
+func (tm *TemplateManager) AddContextProcessor(processor func(*http.Request) Params) *TemplateManager {
+	tm.contextProcessors = append(tm.contextProcessors, processor)
+
+	return tm
+}
+
+// Adds several context processors (see `AddContextProcessor()`)
+func (tm *TemplateManager) AddContextProcessors(processors []func(*http.Request) Params) *TemplateManager {
+	for _, processor := range processors {
+		tm.AddContextProcessor(processor)
+	}
+
+	return tm
+}
+
+// Adds a single variable (`name`) with value `value` that will be available in every template within `directory`
+// (relative to the templates directory, "" for all templates) and its subdirectories
+func (tm *TemplateManager) AddDirectoryParam(directory string, name string, value any) *TemplateManager {
+	directory = cleanDirectory(directory)
+
+	if _, ok := tm.directoryParams[directory]; !ok {
+		tm.directoryParams[directory] = make(Params)
+	}
+
+	tm.directoryParams[directory][name] = value
+
+	return tm
+}
+
+// Adds several variables (`params`) that will be available in every template within `directory` and its subdirectories
+func (tm *TemplateManager) AddDirectoryParams(directory string, params Params) *TemplateManager {
+	for name, param := range params {
+		tm.AddDirectoryParam(directory, name, param)
+	}
+
+	return tm
+}
+
+// Adds a single variable (`name`) with value `value` that will be available in every template
+func (tm *TemplateManager) AddGlobalParam(name string, value any) *TemplateManager {
+	tm.globalParams[name] = value
+
+	return tm
+}
+
+// Adds several variables (`params`) that will be available in every template
+func (tm *TemplateManager) AddGlobalParams(params Params) *TemplateManager {
+	for name, param := range params {
+		tm.AddGlobalParam(name, param)
+	}
+
+	return tm
+}
+
+// Adds a locale that templates can be rendered in (via a `Locale` variable or `WithLocale()`) even though it has no
+// catalog, so that its numbers are formatted correctly. Locales with a catalog are always available
+func (tm *TemplateManager) AddLocale(locale string) *TemplateManager {
+	tm.locales = append(tm.locales, locale)
+
+	return tm
+}
+
+// Adds multiple locales that templates can be rendered in (see `AddLocale()`)
+func (tm *TemplateManager) AddLocales(locales []string) *TemplateManager {
+	tm.locales = append(tm.locales, locales...)
+
+	return tm
+}
+
+// Sets the directory (e.g. "data") whose JSON, YAML, TOML and CSV files are loaded and made available to every
+// template as `.Data` (Default: none)
+func (tm *TemplateManager) DataDirectory(directory string) *TemplateManager {
+	tm.dataDirectory = strings.TrimSuffix(directory, "/")
+
+	return tm
+}
+
+// Sets the locale used when a render does not choose one (via a `Locale` variable or `WithLocale()`), and for
+// messages that are missing from the chosen locale's catalog (default: "en")
+func (tm *TemplateManager) DefaultLocale(locale string) *TemplateManager {
+	tm.defaultLocale = locale
+
+	return tm
+}
+
 // Sets the delimiters used by `text/template` (Default: "{{" and "}}")
 func (tm *TemplateManager) Delimiters(left string, right string) *TemplateManager {
 	tm.delimiterLeft	= left
@@ -265,6 +433,50 @@ func (tm *TemplateManager) ExcludeDirectory(directory string) *TemplateManager {
 	return tm
 }
 
+// Explains the variables that the `name` template would be rendered with (given `data`, as passed to `Render()`).
+// Every variable is listed (sorted by name) with its final value, the source of that value (e.g. "render",
+// "var in layouts/main.html" or "global") and the values that it overrode, most recent last
+func (tm *TemplateManager) ExplainParams(name string, data any) ([]ParamExplanation, error) {
+	if ! tm.parsed {
+		err := tm.Parse()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return tm.explainParams(name, data, nil)
+}
+
+// Allows multi-word components to also be used with kebab-case tags (e.g. `<video-embed>` for `VideoEmbed.html`)
+// so that markup remains valid for HTML linters and editors. Single word components are unaffected.
+func (tm *TemplateManager) KebabCaseComponents(kebab bool) *TemplateManager {
+	tm.kebabComponents = kebab
+
+	return tm
+}
+
+// Returns the front matter declared at the top of the `name` template file (e.g. its title, route or tags).
+// Templates without front matter return empty `Params`
+func (tm *TemplateManager) Meta(name string) Params {
+	if ! tm.parsed {
+		err := tm.Parse()
+		if err != nil {
+			logError(err.Error())
+			return Params{}
+		}
+	}
+
+	tm.mutex.RLock()
+	defer tm.mutex.RUnlock()
+
+	meta := Params{}
+	for key, value := range tm.meta[name] {
+		meta[key] = value
+	}
+
+	return meta
+}
+
 // Allows the `text/template` Option `missingkey` to be customised.
 // This setting controls what happens when an unset value is printed (i.e. {{ .Unset }}).
 // Valid Options: 
@@ -297,19 +509,45 @@ func (tm *TemplateManager) Parse() error {
 		logWarning("Parsing all components...")
 	}
 
-	tm.parseComponents()
+	err := tm.parseComponents()
+	if err != nil {
+		return err
+	}
+
+	if len(tm.dataDirectory) > 0 && tm.debug {
+		logWarning("Loading all data files...")
+	}
+
+	err = tm.loadData()
+	if err != nil {
+		return err
+	}
+
+	if len(tm.translationsDirectory) > 0 && tm.debug {
+		logWarning("Loading all translations...")
+	}
+
+	err = tm.loadTranslations()
+	if err != nil {
+		return err
+	}
 
 	if tm.debug {
 		logWarning("Parsing all templates...")
 	}
 
-	var err error
+	tm.directoryFileParams = make(map[string]Params)
 
 	walk := func(path string, info fs.DirEntry, err error) error {
 		if err != nil || info == nil {
 			return err
 		}
 
+		if !info.IsDir() && filepath.Base(path) == directoryParamsFile {
+			directory, _ := cleanPath(filepath.Dir(path), tm.directory)
+			return tm.loadDirectoryParams(cleanDirectory(directory))
+		}
+
 		_, err = hasExtension(path, tm.extensions)
 		if err != nil {
 			return nil
@@ -342,6 +580,10 @@ func (tm *TemplateManager) Parse() error {
 		err = filepath.WalkDir(tm.directory, walk)
 	}
 
+	if err == nil {
+		err = tm.checkViews()
+	}
+
 	if err == nil {
 		tm.parsed = true
 
@@ -458,41 +700,57 @@ func (tm *TemplateManager) RenameFunctions(functions map[string]string) *Templat
 	return tm
 }
 
-// Executes a single template (`name`)
-func (tm *TemplateManager) Render(name string, params Params, writer io.Writer) error {
-	if ! tm.parsed {
-		err := tm.Parse()
-		if err != nil {
-			err = logError(err.Error())
-			return err
+// Executes a single template (`name`) with `data`, which may be `Params`, any map with string keys or a struct
+// (whose exported fields become variables). The data is copied, so it is never modified
+func (tm *TemplateManager) Render(name string, data any, writer io.Writer) error {
+	return tm.render(name, data, nil, writer)
+}
+
+// Executes a single template (`name`) with the variables created by all context processors from the `request`.
+// These have a lower precedence than `data`, but a higher precedence than all other variables
+func (tm *TemplateManager) RenderRequest(writer io.Writer, request *http.Request, name string, data any) error {
+	requestParams := Params{}
+	if locale, ok := requestLocale(request); ok {
+		requestParams["Locale"] = locale
+	}
+
+	for _, processor := range tm.contextProcessors {
+		for key, value := range processor(request) {
+			requestParams[key] = value
 		}
 	}
 
-	if tm.reload {
-		err := tm.reParseIndividualTemplate(tm.directory + "/" + name)
+	return tm.render(name, data, requestParams, writer)
+}
+
+// Returns the schema of the `name` template: every field that its bundle uses (following `range`, `with` and
+// `template`), and whether each top level field is already provided by vars or other params
+func (tm *TemplateManager) Schema(name string) (*Schema, error) {
+	if ! tm.parsed {
+		err := tm.Parse()
 		if err != nil {
-			err = logError(err.Error())
-			return err
+			return nil, err
 		}
 	}
 
-	tmpl, err := tm.find(name)
-	if err != nil {
-		err = logError(err.Error())
-		return err
-	}
+	tm.mutex.RLock()
+	defer tm.mutex.RUnlock()
 
-	params = tm.buildParams(name, params)
-	
-	buf := &bytes.Buffer{}
-	err = tmpl.Execute(buf, params)
-	if err != nil {
-		err = logError("FATAL: " + err.Error())
-		return err
+	return tm.buildSchema(name)
+}
+
+// Checks the params of every render against the template's schema, so that missing top level fields are caught.
+// Valid Options:
+// "off" (default), "warn" (logs a warning) or "error" (logs an error and halts rendering)
+func (tm *TemplateManager) Strict(mode string) *TemplateManager {
+	mode = strings.ToLower(mode)
+	if mode == "off" || mode == "warn" || mode == "error" {
+		tm.strict = mode
+	} else {
+		panic("invalid strict mode chosen: " + mode)
 	}
 
-	buf.WriteTo(writer)
-	return nil
+	return tm
 }
 
 // Sets whether `TemplateManager` should use the `text/template` package or the `html/template` package
@@ -509,6 +767,14 @@ func (tm *TemplateManager) TemplateEngine(engine string) *TemplateManager {
 	return tm
 }
 
+// Sets the directory containing the message catalogs used by the `t` and `tn` functions. Catalogs are JSON, YAML or
+// gettext .po files named after their locale (e.g. `translations/fr.json` or `translations/fr/checkout.po`)
+func (tm *TemplateManager) TranslationsDirectory(directory string) *TemplateManager {
+	tm.translationsDirectory = directory
+
+	return tm
+}
+
 // Adds the default functions to the `TemplateManager` instance
 func (tm *TemplateManager) addDefaultFunctions() *TemplateManager {
 	tm.AddFunctions(getDefaultFunctions())
@@ -526,25 +792,134 @@ func (tm *TemplateManager) addDescendant(templateName string, descendant string)
 	return tm
 }
 
-// Readies the parameters for a single template using nested variables from descendants
-// Uses a simple hierarchy to determine which should be included
-func (tm *TemplateManager) buildParams(name string, params Params) Params {
-	var descendants = []string{name}
-	if dependencies, ok := tm.descendants[name]; ok {
-		descendants = append(descendants, dependencies...)
+// Executes a single template (`name`) with the explicit `data` and the variables created from the request (if any)
+func (tm *TemplateManager) render(name string, data any, requestParams Params, writer io.Writer) error {
+	if ! tm.parsed {
+		err := tm.Parse()
+		if err != nil {
+			err = logError(err.Error())
+			return err
+		}
+	}
+
+	if tm.reload {
+		err := tm.reParseIndividualTemplate(tm.directory + "/" + name)
+		if err != nil {
+			err = logError(err.Error())
+			return err
+		}
+
+		tm.mutex.Lock()
+		err = tm.loadData()
+		if err == nil {
+			err = tm.loadTranslations()
+		}
+		for _, directory := range templateDirectories(name) {
+			if err == nil {
+				err = tm.loadDirectoryParams(directory)
+			}
+		}
+		tm.mutex.Unlock()
+		if err != nil {
+			err = logError(err.Error())
+			return err
+		}
+	}
+
+	tmpl, err := tm.find(name)
+	if err != nil {
+		err = logError(err.Error())
+		return err
+	}
+
+	params, err := tm.buildParams(name, data, requestParams)
+	if err != nil {
+		err = logError(err.Error())
+		return err
+	}
+
+	if locale, ok := params["Locale"].(string); ok {
+		if localised := tm.localisedTemplate(name, tm.matchLocale(locale)); localised != nil && localised.Lookup(name) != nil {
+			tmpl = localised.Lookup(name)
+		}
 	}
 
-	for _, descendant := range descendants {
-		if templateParams, ok := tm.params[descendant]; ok {
-			for key, value := range templateParams {
-				if _, ok := params[key]; !ok {
-					params[key] = value
+	if tm.strict != "off" {
+		if schema, ok := tm.schemas[name]; ok {
+			missing := missingSchemaFields(schema, params)
+			if len(missing) > 0 && tm.strict == "error" {
+				err = logError(missingSchemaFieldsError(name, missing))
+				return err
+			} else if len(missing) > 0 {
+				err = logWarning(missingSchemaFieldsError(name, missing))
+				if err != nil {
+					return err
 				}
 			}
 		}
 	}
 
-	return params
+	if tm.debug {
+		explanations, err := tm.explainParams(name, data, requestParams)
+		if err == nil {
+			logInformation(fmt.Sprintf("Rendering: %s", name))
+			fmt.Print(formatParamExplanations(explanations))
+		}
+	}
+
+	buf := &bytes.Buffer{}
+	err = tmpl.Execute(buf, params)
+	if err != nil {
+		err = logError("FATAL: " + err.Error())
+		return err
+	}
+
+	_, err = writer.Write(tm.injectComponentAssets(buf.Bytes()))
+	return err
+}
+
+// Readies the parameters for a single template by merging all of its layers (see `paramLayers()`)
+// The result is always a new map, so neither `data` nor any stored variables are modified
+func (tm *TemplateManager) buildParams(name string, data any, requestParams Params) (Params, error) {
+	params, err := dataToParams(data)
+	if err != nil {
+		return nil, err
+	}
+
+	params = mergeParamLayers(tm.paramLayers(name, params, requestParams))
+
+	err = evaluateParams(params)
+	if err != nil {
+		return nil, err
+	}
+
+	return params, nil
+}
+
+// Explains the variables of a single template (see `ExplainParams()`), including those from the request (if any)
+func (tm *TemplateManager) explainParams(name string, data any, requestParams Params) ([]ParamExplanation, error) {
+	params, err := tm.buildParams(name, data, requestParams)
+	if err != nil {
+		return nil, err
+	}
+
+	renderParams, _	:= dataToParams(data)
+	explanations	:= explainParamLayers(tm.paramLayers(name, renderParams, requestParams))
+
+	keys := []string{}
+	for key := range explanations {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	result := []ParamExplanation{}
+	for _, key := range keys {
+		explanation			:= *explanations[key]
+		explanation.Value	= params[key]
+		result				= append(result, explanation)
+	}
+
+	return result, nil
 }
 
 // Finds an individual template bundle from the `TemplateManager`
@@ -570,29 +945,66 @@ func (tm *TemplateManager) initComponentRegexps() {
 		regexps["findGeneratedDefines"]		= findGeneratedDefines
 		regexps["findCollectionComponents"] = findCollectionComponents
 
-		for component := range tm.components {
-			findComponentsDouble, _				:= regexp.Compile(`(?s)<` + component + `(\s+[^>]*)?\s*>(.*?)</` + component + `>`)
-			findComponentsSingle, _				:= regexp.Compile(`(?s)<` + component + `(\s+[^>]*)?\s*>`)
-			findComponentsCollectedDouble, _	:= regexp.Compile(`(?s)<x-` + component + `(\s+[^>]*)?\s*>(.*?)</x-` + component + `>`)
-			findComponentsCollectedSingle, _	:= regexp.Compile(`(?s)<x-` + component + `(\s+[^>]*)?\s*>`)
+		delete(regexps, "findAmbiguousComponents")
+		if len(tm.ambiguousComponents) > 0 {
+			names := []string{}
+			for name := range tm.ambiguousComponents {
+				names = append(names, regexp.QuoteMeta(name))
+			}
+			sort.Strings(names)
+
+			regexps["findAmbiguousComponents"] = regexp.MustCompile(`<(?:x-)?(` + strings.Join(names, "|") + `)[\s/>]`)
+		}
 
-			regexps[component + "_findComponentsDouble"]			= findComponentsDouble
-			regexps[component + "_findComponentsSingle"]			= findComponentsSingle
-			regexps[component + "_findComponentsCollectedDouble"]	= findComponentsCollectedDouble
-			regexps[component + "_findComponentsCollectedSingle"]	= findComponentsCollectedSingle
+		for component := range tm.components {
+			tag := regexp.QuoteMeta(component)
+
+			findComponentsSelfClosing, _			:= regexp.Compile(`(?s)<` + tag + `(\s+[^>]*?)?\s*/>`)
+			findComponentsDouble, _					:= regexp.Compile(`(?s)<` + tag + `(\s+[^>]*[^/>])?\s*>(.*?)</` + tag + `>`)
+			findComponentsSingle, _					:= regexp.Compile(`(?s)<` + tag + `(\s+[^>]*[^/>])?\s*>`)
+			findComponentsCollectedSelfClosing, _	:= regexp.Compile(`(?s)<x-` + tag + `(\s+[^>]*?)?\s*/>`)
+			findComponentsCollectedDouble, _		:= regexp.Compile(`(?s)<x-` + tag + `(\s+[^>]*[^/>])?\s*>(.*?)</x-` + tag + `>`)
+			findComponentsCollectedSingle, _		:= regexp.Compile(`(?s)<x-` + tag + `(\s+[^>]*[^/>])?\s*>`)
+
+			regexps[component + "_findComponentsSelfClosing"]			= findComponentsSelfClosing
+			regexps[component + "_findComponentsDouble"]				= findComponentsDouble
+			regexps[component + "_findComponentsSingle"]				= findComponentsSingle
+			regexps[component + "_findComponentsCollectedSelfClosing"]	= findComponentsCollectedSelfClosing
+			regexps[component + "_findComponentsCollectedDouble"]		= findComponentsCollectedDouble
+			regexps[component + "_findComponentsCollectedSingle"]		= findComponentsCollectedSingle
 		}
 	}
 }
 
+// Returns an error if the content uses a bare component tag that is shared by more than one component
+func (tm *TemplateManager) checkAmbiguousComponents(content string) error {
+	find, ok := regexps["findAmbiguousComponents"]
+	if !ok || len(tm.ambiguousComponents) == 0 {
+		return nil
+	}
+
+	match := find.FindStringSubmatch(content)
+	if match == nil {
+		return nil
+	}
+
+	name	:= match[1]
+	paths	:= tm.ambiguousComponents[name]
+
+	return fmt.Errorf("component <%s> is defined by both %s and %s, so it must be used with a namespace", name, strings.Join(paths[:len(paths) - 1], ", "), paths[len(paths) - 1])
+}
+
 // Initialises the regexps required by the file scanning
 func (tm *TemplateManager) initRegexps() {
-	findVars, _					:= regexp.Compile("(?s)\\s*" + tm.delimiterLeft + "(?:- )?(?:\\/\\*)?\\s*var\\s*[\"`]{1}\\s*([^\"]+)\\s*[\"`]{1}.*?" + tm.delimiterRight + "\\s*(.*?)\\s*" + tm.delimiterLeft + "\\s*end\\s*(?:\\*\\/)?(?: -)?" + tm.delimiterRight + "\\s*")
+	findVars, _					:= regexp.Compile("(?s)\\s*" + tm.delimiterLeft + "(?:- )?(?:\\/\\*)?\\s*var\\s*[\"`]{1}\\s*([^\"]+)\\s*[\"`]{1}((?:\\s+[a-z]+)*).*?" + tm.delimiterRight + "\\s*(.*?)\\s*" + tm.delimiterLeft + "\\s*end\\s*(?:\\*\\/)?(?: -)?" + tm.delimiterRight + "\\s*")
 	findExtends, _				:= regexp.Compile("^\\s*" + tm.delimiterLeft + "(?:- )?(?:\\/\\*)?\\s*extends\\s*[\"`]{1}([^\"`]+)[\"`]{1}\\s*(?:\\*\\/)?(?: -)?" + tm.delimiterRight + "\\s*")
 	findTemplates, _			:= regexp.Compile(tm.delimiterLeft + "\\-?\\s*template\\s*[\"`]{1}([^\"`]+)[\"`]{1}.*?\\-?" + tm.delimiterRight)
+	findComponentExamples, _	:= regexp.Compile("(?s)\\s*" + tm.delimiterLeft + "(?:- )?(?:\\/\\*)?\\s*examples\\s*(?:\\*\\/)?(?: -)?" + tm.delimiterRight + "\\s*(.*?)\\s*" + tm.delimiterLeft + "(?:- )?(?:\\/\\*)?\\s*end\\s*(?:\\*\\/)?(?: -)?" + tm.delimiterRight + "\\s*")
 
-	regexps["findVars"]			= findVars
-	regexps["findExtends"]		= findExtends
-	regexps["findTemplates"]	= findTemplates
+	regexps["findVars"]					= findVars
+	regexps["findExtends"]				= findExtends
+	regexps["findTemplates"]			= findTemplates
+	regexps["findComponentExamples"]	= findComponentExamples
 }
 
 // Re-parses an individual template file (if reload is enabled)
@@ -602,7 +1014,7 @@ func (tm *TemplateManager) reParseIndividualTemplate(path string) error {
 		return err
 	}
 
-	tm.params[name]			= make(Params)
+	tm.meta[name]			= make(Params)
 	tm.descendants[name]	= []string{}
 
 	if tm.debug {
@@ -615,54 +1027,132 @@ func (tm *TemplateManager) reParseIndividualTemplate(path string) error {
 		return err
 	}
 
-	return nil
+	return tm.checkTemplateViews(name)
 }
 
-func (tm *TemplateManager) parseComponents() error {
-	var err error
-
-	walk := func(path string, info fs.DirEntry, err error) error {
-		if err != nil || info == nil {
-			return err
-		}
+// Loads the `_params.json` file of a single directory (relative to the templates directory), if it has one
+func (tm *TemplateManager) loadDirectoryParams(directory string) error {
+	file := tm.directory + "/" + directoryParamsFile
+	if len(directory) > 0 {
+		file = tm.directory + "/" + directory + "/" + directoryParamsFile
+	}
 
-		extension, err := hasExtension(path, tm.extensions)
-		if err != nil {
-			return nil
-		}
+	delete(tm.directoryFileParams, directory)
 
-		if info.IsDir() {
+	buffer, err := fsWalk.ReadFile(file, tm.fileSystem)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
 			return nil
 		}
+		return err
+	}
 
-		componentPath, _	:= cleanPath(path, tm.directory)
-		index				:= strings.LastIndex(componentPath, "/")
-		name				:= stripExtension(componentPath[index + 1:], extension)
+	params, err := parseParamsDocument("json", string(buffer))
+	if err != nil {
+		return fmt.Errorf("%s: invalid params: %s", file, err.Error())
+	}
 
-		tm.components[name] = componentPath
+	tm.directoryFileParams[directory] = params
 
-		return nil
+	if tm.debug {
+		logInformation(fmt.Sprintf("Loaded directory params: %s\n", file))
 	}
 
-	for _, componentDirectory := range tm.componentDirectories {
-		if tm.fileSystem != nil {
-			err = fsWalk.WalkDir(tm.fileSystem, tm.directory + "/" + componentDirectory, walk)
-		} else {
-			err = filepath.WalkDir(tm.directory + "/" + componentDirectory, walk)
-		}
+	return nil
+}
+
+// Scans all component directories and registers each component under the tag names it may be used with.
+// Every component may be used namespaced by its subdirectory (`<forms:Button>` / `<Forms.Button>`), or by its
+// component directory if it is at the root of one (`<components:Button>`), and bare (`<Button>`) if no other component
+// shares its name. Shared bare names must be used with their namespace (using them bare is a parse error).
+func (tm *TemplateManager) parseComponents() error {
+	tm.components			= make(map[string]string)
+	tm.ambiguousComponents	= make(map[string][]string)
+	bare					:= map[string][]string{}
+
+	for _, componentDirectory := range tm.componentDirectories {
+		root := tm.directory + "/" + componentDirectory
+
+		walk := func(path string, info fs.DirEntry, err error) error {
+			if err != nil || info == nil {
+				if path == root && errors.Is(err, fs.ErrNotExist) {
+					return nil
+				}
+				return err
+			}
+
+			extension, err := hasExtension(path, tm.extensions)
+			if err != nil {
+				return nil
+			}
+
+			if info.IsDir() {
+				return nil
+			}
+
+			componentPath, _	:= cleanPath(path, tm.directory)
+			relativePath, _		:= cleanPath(path, root)
+
+			names, namespaced := componentNames(componentDirectory, relativePath, extension, tm.kebabComponents)
+			for _, name := range names {
+				if !slices.Contains(bare[name], componentPath) {
+					bare[name] = append(bare[name], componentPath)
+				}
+			}
+
+			for _, name := range namespaced {
+				err = tm.addComponent(name, componentPath)
+				if err != nil {
+					return err
+				}
+			}
+
+			return nil
+		}
+
+		var err error
+		if tm.fileSystem != nil {
+			err = fsWalk.WalkDir(tm.fileSystem, root, walk)
+		} else {
+			err = filepath.WalkDir(root, walk)
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	for name, paths := range bare {
+		if len(paths) > 1 {
+			sort.Strings(paths)
+			tm.ambiguousComponents[name] = paths
+			continue
+		}
+
+		err := tm.addComponent(name, paths[0])
+		if err != nil {
+			return err
+		}
 	}
 
 	tm.initComponentRegexps()
 
-	if err == nil {
-		tm.parsed = true
+	if tm.debug {
+		logSuccess("All components parsed and ready to use")
+	}
 
-		if tm.debug {
-			logSuccess("All components parsed and ready to use")
-		}
+	return nil
+}
+
+// Registers a namespaced (or unique bare) component tag name, refusing to let two different files share the same name
+func (tm *TemplateManager) addComponent(name string, componentPath string) error {
+	if existing, ok := tm.components[name]; ok && existing != componentPath {
+		return fmt.Errorf("component <%s> is defined by both %s and %s, one of them must be renamed", name, existing, componentPath)
 	}
 
-	return err
+	tm.components[name] = componentPath
+
+	return nil
 }
 
 // Handles parsing an individual file
@@ -690,6 +1180,17 @@ func (tm *TemplateManager) parseFileDependencies(path string, name string, direc
 	if err != nil {
 		return err
 	}
+
+	schema, err := tm.buildSchema(name)
+	if err != nil {
+		return err
+	}
+	tm.schemas[name] = schema
+
+	err = tm.localiseTemplate(name)
+	if err != nil {
+		return err
+	}
 	
 	if tm.debug {
 		logInformation(fmt.Sprintf("Parsed template: %s (Path: %s)\n", name, path))
@@ -708,16 +1209,20 @@ func (tm *TemplateManager) parseFileDependencies(path string, name string, direc
 			descendants = append([]string{name}, dependencies...)
 		}
 		for _, descendant := range descendants {
-			if templateParams, ok := tm.params[descendant]; ok {
+			if templateVars, ok := tm.vars[descendant]; ok && len(templateVars) > 0 {
 				vars = vars + fmt.Sprintf("\t\tSet in: %s\n", descendant)
 				keys := []string{}
   
-				for k := range templateParams{
+				for k := range templateVars {
 					keys = append(keys, k)
 				}
 				sort.Strings(keys)
 				for _, k := range keys {
-					vars = vars + fmt.Sprintf("\t\t\t%s (%T) = %v\n", k, templateParams[k], templateParams[k])
+					modifier := ""
+					if len(templateVars[k].modifier) > 0 {
+						modifier = " [" + templateVars[k].modifier + "]"
+					}
+					vars = vars + fmt.Sprintf("\t\t\t%s%s (%T) = %v\n", k, modifier, templateVars[k].value, templateVars[k].value)
 				}
 			}
 		}
@@ -735,24 +1240,40 @@ func (tm *TemplateManager) configureNewTemplate(tmpl *Template) *Template {
 	tmpl.Delims(tm.delimiterLeft, tm.delimiterRight)
 	tmpl.Option("missingkey=" + tm.missingKey)
 	tmpl.Funcs(tm.functions)
+	tmpl.Funcs(tm.localeFunctions(""))
 	tmpl.Funcs(map[string]any {
-		"render": func(name string, args ...any) string {
-			var data any = nil
-			if len(args) > 0 {
-				data = args[0]
-			}
-			buf := &bytes.Buffer{}
-			err := tmpl.ExecuteTemplate(buf, name, data)
-			if err != nil {
-				return ""
-			}
-			return buf.String()
-        },
+		"render": templateRenderFunction(tmpl),
+		"componentRoot": componentRoot,
+		"componentRootParams": componentRootParams,
+		"viewMethod": viewMethod,
+		"componentInherit": componentInherit,
+		"componentStyles": func() string {
+			return componentAssetMarker + "-styles"
+		},
+		"componentScripts": func() string {
+			return componentAssetMarker + "-scripts"
+		},
 	})
 
 	return tmpl
 }
 
+// Creates the `render` function of a template, which executes one of its named templates and returns the output
+func templateRenderFunction(tmpl *Template) func(string, ...any) string {
+	return func(name string, args ...any) string {
+		var data any = nil
+		if len(args) > 0 {
+			data = args[0]
+		}
+		buf := &bytes.Buffer{}
+		err := tmpl.ExecuteTemplate(buf, name, data)
+		if err != nil {
+			return ""
+		}
+		return buf.String()
+	}
+}
+
 // Adds the file contents to the bundle
 func (tm *TemplateManager) addTemplate(path string, name string, directory string, tmpl *Template) error {
 	contents, err := tm.getFileContents(path, directory)
@@ -780,21 +1301,58 @@ func (tm *TemplateManager) getFileContents(path string, directory string) ([]str
 	}
 	content  := string(buffer)
 	contents := []string{}
+	name, _  := cleanPath(path, directory)
+
+	tm.vars[name] = make(map[string]templateVar)
+
+	// Front matter is removed first, so that vars which follow it can't consume the end of its block
+	block, format, body := findFrontMatter(content)
+	if len(block) > 0 {
+		content = strings.TrimPrefix(strings.TrimPrefix(content, "\uFEFF"), block)
+	}
 
 	if regexps["findVars"].MatchString(content) {
-		matches := regexps["findVars"].FindAllStringSubmatch(content, -1)
-		name, _ := cleanPath(path, directory)
+		matches	:= regexps["findVars"].FindAllStringSubmatchIndex(content, -1)
+		source	:= content
 
 		for _, match := range matches {
-			content = strings.Replace(content, match[0], "", 1)
-			varName := string(match[1])
-			if _, ok := tm.params[name][varName]; !ok {
-				tm.parseVariable(name, varName, match[2])
+			content = strings.Replace(content, source[match[0]:match[1]], "", 1)
+			varName := source[match[2]:match[3]]
+			if _, ok := tm.vars[name][varName]; !ok {
+				modifier, eval, err := parseVarModifiers(source[match[4]:match[5]])
+				if err != nil {
+					return []string{}, variableError(name, source, match[2], varName, err)
+				}
+
+				if eval {
+					err = tm.parseEvalVariable(name, varName, modifier, source[match[6]:match[7]])
+				} else {
+					err = tm.parseVariable(name, varName, modifier, source[match[6]:match[7]])
+				}
+				if err != nil {
+					return []string{}, variableError(name, source, match[6], varName, err)
+				}
 			}
 		}
 	}
 
-	if regexps["findExtends"].MatchString(content) {
+	if len(block) > 0 {
+		meta, err := parseParamsDocument(format, body)
+		if err != nil {
+			return []string{}, fmt.Errorf("%s: invalid %s front matter: %s", name, format, err.Error())
+		}
+
+		for key, value := range meta {
+			if _, ok := tm.vars[name][key]; !ok {
+				tm.vars[name][key] = templateVar{value: value}
+			}
+		}
+
+		tm.meta[name] = meta
+	}
+
+	extends := regexps["findExtends"].MatchString(content)
+	if extends {
 		matches := regexps["findExtends"].FindAllStringSubmatch(content, -1)
 		content = strings.Replace(content, matches[0][0], "", 1)
 		contents, err = tm.getFileContents(directory + "/" + matches[0][1], directory)
@@ -803,8 +1361,24 @@ func (tm *TemplateManager) getFileContents(path string, directory string) ([]str
 		}
 	}
 
+	if isMarkdownFile(path) {
+		content, err = tm.parseMarkdownTemplate(content, extends)
+		if err != nil {
+			return []string{}, fmt.Errorf("%s: invalid markdown: %s", name, err.Error())
+		}
+	}
+
+	content, err = tm.parseTransBlocks(content)
+	if err != nil {
+		return []string{}, fmt.Errorf("%s: %s", name, err.Error())
+	}
 	content = tm.parseContentComponents(content, directory)
 
+	err = tm.checkAmbiguousComponents(content)
+	if err != nil {
+		return []string{}, fmt.Errorf("%s: %s", name, err.Error())
+	}
+
 	return append(contents, content), nil
 }
 
@@ -814,7 +1388,8 @@ func (tm *TemplateManager) getFileDependencies(path string, directory string) ([
 	if err != nil {
 		return []string{}, err
 	}
-	dependencies := []string{}
+	buffer			= stripFrontMatter(buffer)
+	dependencies	:= []string{}
 
 	if regexps["findExtends"].Match(buffer) {
 		matches := regexps["findExtends"].FindAllSubmatch(buffer, -1)
@@ -845,19 +1420,156 @@ func (tm *TemplateManager) getFileDependencies(path string, directory string) ([
 	return dependencies, nil
 }
 
+// Moves the `style` and `script` sections of a component's content to its start, marked so that they can be
+// hoisted into the layout once rendered. The sections are executed with the component's data like the rest of it
+func (tm *TemplateManager) parseComponentAssets(componentPath string, content string) string {
+	// Examples are only used by the component gallery
+	if match := regexps["findComponentExamples"].FindStringSubmatch(content); match != nil {
+		content = strings.Replace(content, match[0], "", 1)
+	}
+
+	assets := ""
+	for _, section := range []string{"style", "script"} {
+		start, end, body, ok := tm.findTemplateSection(content, section)
+		if !ok {
+			continue
+		}
+
+		assets	+= componentAssetMarker + "-" + section + ":" + componentPath + ";" + body + componentAssetMarker + "-end;"
+		content	= strings.TrimRightFunc(content[:start], unicode.IsSpace) + strings.TrimLeftFunc(content[end:], unicode.IsSpace)
+	}
+
+	return assets + content
+}
+
+/*
+Finds the first `keyword` section of a template (e.g. a component's `{{ style }} ... {{ end }}`), matching its `end`
+past any blocks nested within it (e.g. `{{ if }} ... {{ end }}`). Returns the position of the whole section and its trimmed body
+*/
+func (tm *TemplateManager) findTemplateSection(content string, keyword string) (int, int, string, bool) {
+	actions := findTemplateActions(content, tm.delimiterLeft, tm.delimiterRight)
+	for i, action := range actions {
+		if tm.actionKeyword(content[action[0]:action[1]]) != keyword {
+			continue
+		}
+
+		depth := 1
+		for _, closing := range actions[i + 1:] {
+			switch tm.actionKeyword(content[closing[0]:closing[1]]) {
+				case "if", "range", "with", "block", "define", "style", "script", "examples", "trans":
+					depth++
+				case "end":
+					depth--
+			}
+
+			if depth == 0 {
+				return action[0], closing[1], strings.TrimSpace(content[action[1]:closing[0]]), true
+			}
+		}
+
+		return 0, 0, "", false
+	}
+
+	return 0, 0, "", false
+}
+
+// Finds the first word of an action (e.g. "if" for `{{- if .Visible }}`)
+func (tm *TemplateManager) actionKeyword(action string) string {
+	action = strings.TrimSuffix(strings.TrimPrefix(action, tm.delimiterLeft), tm.delimiterRight)
+	action = strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(action, "-"), "-"))
+	if fields := strings.Fields(action); len(fields) > 0 {
+		return fields[0]
+	}
+
+	return ""
+}
+
+// Replaces the `componentStyles` and `componentScripts` placeholders in rendered output with the (deduplicated)
+// sections of every component used, in the order in which they were first used
+func (tm *TemplateManager) injectComponentAssets(output []byte) []byte {
+	if !bytes.Contains(output, []byte(componentAssetMarker)) {
+		return output
+	}
+
+	used, styles, scripts := []string{}, []string{}, []string{}
+	for _, match := range regexps["findComponentAssets"].FindAllSubmatch(output, -1) {
+		key := string(match[1]) + ":" + string(match[2])
+		if slices.Contains(used, key) {
+			continue
+		}
+		used = append(used, key)
+
+		if string(match[1]) == "style" {
+			styles = append(styles, string(match[3]))
+		} else {
+			scripts = append(scripts, string(match[3]))
+		}
+	}
+
+	output = regexps["findComponentAssets"].ReplaceAll(output, []byte{})
+	output = bytes.ReplaceAll(output, []byte(componentAssetMarker + "-styles"), []byte(strings.Join(styles, "\n")))
+	output = bytes.ReplaceAll(output, []byte(componentAssetMarker + "-scripts"), []byte(strings.Join(scripts, "\n")))
+
+	return output
+}
+
+// Builds the `collection` arguments passed to a component: its uuid, content template name, any inherited parameters
+// and finally its own attributes (which take precedence over inherited values)
+func (tm *TemplateManager) componentCollection(id string, attributes string) string {
+	collection	:= `"ComponentUuid" "` + id + `" "ComponentContent" "content-` + id + `" "` + componentRootKey + `" (componentRoot $)`
+	inherits	:= append([]string{}, tm.componentInherits...)
+	passed		:= ""
+
+	for _, attribute := range regexps["findAttributes"].FindAllStringSubmatch(attributes, -1) {
+		name := strings.Trim(attribute[1], " ")
+
+		bias := "numeric"
+		if strings.HasPrefix(attribute[2], `"`) {
+			bias = "string"
+		}
+
+		value := strings.Trim(attribute[2], `"`)
+
+		if name == "inherit" {
+			for _, inherit := range strings.FieldsFunc(value, func(r rune) bool { return r == ',' || unicode.IsSpace(r) }) {
+				if !slices.Contains(inherits, inherit) {
+					inherits = append(inherits, inherit)
+				}
+			}
+			continue
+		}
+
+		if bias == "string" {
+			if strings.HasPrefix(value, tm.delimiterLeft) && strings.HasSuffix(value, tm.delimiterRight) {
+				value = strings.TrimRight(strings.TrimLeft(value, tm.delimiterLeft + " "), tm.delimiterRight + " ")
+			} else {
+				value = `"` + value + `"`
+			}
+		}
+
+		passed += ` "` + name + `" ` + value
+	}
+
+	for _, inherit := range inherits {
+		if inherit == "Root" {
+			collection += ` "Root" (componentRootParams (componentRoot $))`
+		} else {
+			collection += ` "` + inherit + `" (componentInherit . "` + inherit + `")`
+		}
+	}
+
+	if len(passed) == 0 {
+		passed = ` "Null" ""`
+	}
+
+	return collection + passed
+}
+
 func (tm *TemplateManager) parseContentComponents(content string, directory string) string {
 	if len(tm.components) > 0 {
 		for component, componentPath := range tm.components {
 			if strings.Contains(content, "<" + component) {	
-				matches := [][]string{}
-
-				if regexps[component + "_findComponentsDouble"].MatchString(content) {
-					matches = regexps[component + "_findComponentsDouble"].FindAllStringSubmatch(content, -1)
-				} else {
-					if regexps[component + "_findComponentsSingle"].MatchString(content) {
-						matches = regexps[component + "_findComponentsSingle"].FindAllStringSubmatch(content, -1)
-					}
-				}
+				matches := findComponentTags(component, content, false)
 
 				for _, match := range matches {
 					random_id	:= uuid.NewString()
@@ -870,29 +1582,7 @@ func (tm *TemplateManager) parseContentComponents(content string, directory stri
 						tagContent = match[2]
 					}
 					
-					replace += ` collection "ComponentUuid" "` + random_id + `" "ComponentContent" "content-` + random_id + `"`
-					if len(attributes) > 0 {
-						attributes := regexps["findAttributes"].FindAllStringSubmatch(attributes, -1)
-						for _, attribute := range attributes {
-							bias := "numeric"
-							if strings.HasPrefix(attribute[2], `"`) {
-								bias = "string"
-							}
-
-							value := strings.Trim(attribute[2], `"`)
-							if bias == "string" {
-								if strings.HasPrefix(value, tm.delimiterLeft) && strings.HasSuffix(value, tm.delimiterRight) {
-									value = strings.TrimRight(strings.TrimLeft(value, tm.delimiterLeft + " "), tm.delimiterRight + " ")
-								} else {
-									value = `"` + value + `"`
-								}
-							}
-
-							replace += ` "` + strings.Trim(attribute[1], " ") + `" ` + value
-						}
-					} else {
-						replace += ` "Null" ""`
-					}
+					replace += ` collection ` + tm.componentCollection(random_id, attributes)
 
 					if len(tagContent) > 0 {
 						// {{- define "content-RANDOM_ID" -}} passed content {{- end -}}
@@ -903,7 +1593,7 @@ func (tm *TemplateManager) parseContentComponents(content string, directory stri
 					if err != nil {
 						continue
 					}
-					componentContent := componentContents[0] // TODO - this is wrong, will fail if extended. Cannot extend?
+					componentContent := tm.parseComponentAssets(componentPath, componentContents[0]) // TODO - this is wrong, will fail if extended. Cannot extend?
 
 					replace += ` -` + tm.delimiterRight + componentContent + tm.delimiterLeft + `- end ` + tm.delimiterRight
 
@@ -911,15 +1601,7 @@ func (tm *TemplateManager) parseContentComponents(content string, directory stri
 				}
 			}
 			if strings.Contains(content, "<x-" + component) {	
-				matches := [][]string{}
-
-				if regexps[component + "_findComponentsCollectedDouble"].MatchString(content) {
-					matches = regexps[component + "_findComponentsCollectedDouble"].FindAllStringSubmatch(content, -1)
-				} else {
-					if regexps[component + "_findComponentsCollectedSingle"].MatchString(content) {
-						matches = regexps[component + "_findComponentsCollectedSingle"].FindAllStringSubmatch(content, -1)
-					}
-				}
+				matches := findComponentTags(component, content, true)
 				for _, match := range matches {
 					random_id	:= uuid.NewString()
 					find		:= match[0]
@@ -931,29 +1613,7 @@ func (tm *TemplateManager) parseContentComponents(content string, directory stri
 						tagContent = match[2]
 					}
 					
-					create := `(collection "ComponentUuid" "` + random_id + `" "ComponentContent" "content-` + random_id + `"`
-					if len(attributes) > 0 {
-						attributes := regexps["findAttributes"].FindAllStringSubmatch(attributes, -1)
-						for _, attribute := range attributes {
-							bias := "numeric"
-							if strings.HasPrefix(attribute[2], `"`) {
-								bias = "string"
-							}
-
-							value := strings.Trim(attribute[2], `"`)
-							if bias == "string" {
-								if strings.HasPrefix(value, tm.delimiterLeft) && strings.HasSuffix(value, tm.delimiterRight) {
-									value = strings.TrimRight(strings.TrimLeft(value, tm.delimiterLeft + " "), tm.delimiterRight + " ")
-								} else {
-									value = `"` + value + `"`
-								}
-							}
-
-							create += ` "` + strings.Trim(attribute[1], " ") + `" ` + value
-						}
-					} else {
-						create += ` "Null" ""`
-					}
+					create := `(collection ` + tm.componentCollection(random_id, attributes)
 
 					if len(tagContent) > 0 {
 						// {{- define "content-RANDOM_ID" -}} passed content {{- end -}}
@@ -964,11 +1624,11 @@ func (tm *TemplateManager) parseContentComponents(content string, directory stri
 					if err != nil {
 						continue
 					}
-					componentContent := componentContents[0] // TODO - this is wrong, will fail if extended. Cannot extend?
+					componentContent := tm.parseComponentAssets(componentPath, componentContents[0]) // TODO - this is wrong, will fail if extended. Cannot extend?
 
 					define += ` -` + tm.delimiterRight + componentContent + tm.delimiterLeft + `- end ` + tm.delimiterRight
 
-					content = tagContent + define + strings.Replace(content, find, tm.delimiterLeft + ` ` + component + ` x-render "` + componentPath + `-` + random_id + `" ` + create + `) ` + tm.delimiterRight, 1)
+					content = tagContent + define + strings.Replace(content, find, tm.delimiterLeft + ` ` + componentVariableName(componentPath) + ` x-render "` + componentPath + `-` + random_id + `" ` + create + `) ` + tm.delimiterRight, 1)
 				}
 			}
 		}
@@ -1033,154 +1693,49 @@ func (tm *TemplateManager) parseContentComponents(content string, directory stri
 	return content
 }
 
-// Parses a variable declared in a template file
-// (this system is very limited to preserve actual types / avoid interfaces and reflection)
-func (tm *TemplateManager) parseVariable(template string, name string, value string) {
-	t, val := getVariableType(value)
-
-	switch t {
-		case "map": tm.parseVariableMap(template, name, value)
-		case "slice": tm.parseVariableSlice(template, name, value)
-		case "int": tm.AddParam(template, name, val.(int))
-		case "float": tm.AddParam(template, name, val.(float64))
-		case "bool": tm.AddParam(template, name, val.(bool))
-		case "string": tm.AddParam(template, name, val.(string))
-	}
-}
-
-// Parses a map variable declared in a template file
-// (this system is very limited to preserve actual types / avoid interfaces and reflection)
-func (tm *TemplateManager) parseVariableMap(template string, name string, value string) {
-	values := prepareMap(value)
-
-	if len(values) == 0 {
-		tm.AddParam(template, name, values)
-		return
-	}
-
-	k, v := "", ""
-	for k, v = range values {
-		break
-	}
-
-	tK, _ := getVariableType(k)
-	tV, _ := getVariableType(v)
-
-	switch tK + tV {
-		case "stringstring": 
-			tm.AddParam(template, name, parseVariableMap[string, string](values))
-		case "stringint":
-			tm.AddParam(template, name, parseVariableMap[string, int](values))
-		case "stringfloat":
-			tm.AddParam(template, name, parseVariableMap[string, float64](values))
-		case "stringbool":
-			tm.AddParam(template, name, parseVariableMap[string, bool](values))
-		case "intstring": 
-			tm.AddParam(template, name, parseVariableMap[int, string](values))
-		case "intint":
-			tm.AddParam(template, name, parseVariableMap[int, int](values))
-		case "intfloat":
-			tm.AddParam(template, name, parseVariableMap[int, float64](values))
-		case "intbool":
-			tm.AddParam(template, name, parseVariableMap[int, bool](values))
-		case "floatstring": 
-			tm.AddParam(template, name, parseVariableMap[float64, string](values))
-		case "floatint":
-			tm.AddParam(template, name, parseVariableMap[float64, int](values))
-		case "floatfloat":
-			tm.AddParam(template, name, parseVariableMap[float64, float64](values))
-		case "floatbool":
-			tm.AddParam(template, name, parseVariableMap[float64, bool](values))
-		case "boolstring": 
-			tm.AddParam(template, name, parseVariableMap[bool, string](values))
-		case "boolint":
-			tm.AddParam(template, name, parseVariableMap[bool, int](values))
-		case "boolfloat":
-			tm.AddParam(template, name, parseVariableMap[bool, float64](values))
-		case "boolbool":
-			tm.AddParam(template, name, parseVariableMap[bool, bool](values))
-	}
-}
-
-// Parses a slice variable declared in a template file.
-// Empty slices are string slices.
-// (this system is very limited to preserve actual types / avoid interfaces and reflection)
-func (tm *TemplateManager) parseVariableSlice(template string, name string, value string) {
-	values := prepareSlice(value)
-
-	if len(values) == 0 {
-		tm.AddParam(template, name, values)
-		return
-	}
-
-	value = values[0]
-	t, _ := getVariableType(value)
-
-	switch t {
-		case "string":
-			tm.AddParam(template, name, values)
-		case "int":
-			tm.AddParam(template, name, parseVariableSlice[int](values))
-		case "float":
-			tm.AddParam(template, name, parseVariableSlice[float64](values))
-		case "bool":
-			tm.AddParam(template, name, parseVariableSlice[bool](values))
-		case "slice":
-			nestedType := "string"
-			if len(values[0]) > 0 {
-				tmp := prepareSlice(values[0])
-				nestedType, _ = getVariableType(tmp[0])
-			}
+// Parses a variable declared in a template file into its actual type
+func (tm *TemplateManager) parseVariable(template string, name string, modifier string, value string) error {
+	val, err := parseVariableLiteral(value)
+	if err != nil {
+		return err
+	}
 
-			switch nestedType {
-				case "int":
-					tm.AddParam(template, name, parseNestedVariableSlice[int](values))
-				case "float":
-					tm.AddParam(template, name, parseNestedVariableSlice[float64](values))
-				case "bool":
-					tm.AddParam(template, name, parseNestedVariableSlice[bool](values))
-				case "string":
-					tm.AddParam(template, name, parseNestedVariableSlice[string](values))
-			}
+	tm.vars[template][name] = templateVar{value: val, modifier: modifier}
+
+	return nil
+}
+
+// Compiles a variable declared in a template file with the `eval` modifier (its value is evaluated at render time)
+func (tm *TemplateManager) parseEvalVariable(template string, name string, modifier string, pipeline string) error {
+	val, err := tm.newEvalVar(template + ":" + name, pipeline)
+	if err != nil {
+		return err
 	}
+
+	tm.vars[template][name] = templateVar{value: val, modifier: modifier}
+
+	return nil
 }
 
 func initRegexps() {
 	findHtmlEntity, _ 			:= regexp.Compile(`&[#a-zA-Z0-9]{0,8};`)
+	findComponentAssets, _		:= regexp.Compile(`(?s)` + componentAssetMarker + `-(style|script):([^;]+);(.*?)` + componentAssetMarker + `-end;`)
 	findAttributes, _			:= regexp.Compile(`(?s)([^=\s]+)\s*=\s*("[^"]+"|[\d\.\-]+)`)
-	findNumericSlice, _			:= regexp.Compile(`\s*([\-\d\.]+)\s*,`)
-	findBooleanSlice, _			:= regexp.Compile(`(?i)\s*(true|false)\s*,`)
-	findStringSlice, _			:= regexp.Compile("\\s*[\"`']{1}(.*?[^\\\\])[\"`']{1}\\s*,")
-	findSliceSlice, _			:= regexp.Compile(`\s*(\[.*?\])\s*,`)
-	findBoolBoolMap, _			:= regexp.Compile(`(?i)(true|false)\s*:\s*(true|false)\s*,`)
-	findBoolNumericMap, _		:= regexp.Compile(`(?i)(true|false)\s*:\s*([\-\.\d]+)\s*,`)
-	findBoolStringMap, _		:= regexp.Compile("(?i)(true|false)\\s*:\\s*[\"`']{1}(.*?[^\\\\])[\"`']{1}\\s*,")
-	findNumericBoolMap, _		:= regexp.Compile(`(?i)([\-\.\d]+)\s*:\s*(true|false)\s*,`)
-	findNumericNumericMap, _	:= regexp.Compile(`([\-\.\d]+)\s*:\s*([\-\.\d]+)\s*,`)
-	findNumericStringMap, _		:= regexp.Compile("([\\-\\.\\d]+)\\s*:\\s*[\"`']{1}(.*?[^\\\\])[\"`']{1}\\s*,")
-	findStringBoolMap, _		:= regexp.Compile("(?i)[\"`']{1}(.*?[^\\\\])[\"`']{1}\\s*:\\s*(true|false)\\s*,")
-	findStringNumericMap, _		:= regexp.Compile("[\"`']{1}(.*?[^\\\\])[\"`']{1}\\s*:\\s*([\\-\\.\\d]+)\\s*,")
-	findStringStringMap, _		:= regexp.Compile("[\"`']{1}(.*?[^\\\\])[\"`']{1}\\s*:\\s*[\"`']{1}(.*?[^\\\\])[\"`']{1}\\s*,")
-	findSliceMap, _				:= regexp.Compile("[\"`']{1}(.*?[^\\\\])[\"`']{1}\\s*:\\s*(\\[.*?\\])\\s*,")
+	findFrontMatterYaml, _		:= regexp.Compile(`(?ms)\A---[ \t]*\r?\n(.*?)^---[ \t]*(?:\r?\n|\z)`)
+	findFrontMatterToml, _		:= regexp.Compile(`(?ms)\A\+\+\+[ \t]*\r?\n(.*?)^\+\+\+[ \t]*(?:\r?\n|\z)`)
+	findPoPluralForms, _		:= regexp.Compile(`(?m)^Plural-Forms:.*?plural\s*=\s*([^;]+)`)
+	findTranslationPlaceholder, _	:= regexp.Compile(`\{[A-Za-z_][A-Za-z0-9_]*\}`)
+	findRelativeDate, _			:= regexp.Compile(`([+-]?\s*\d+)\s*([a-z]+)|([a-z]+)`)
 
 	regexps = map[string]*regexp.Regexp{
 		"findHtmlEntity":			findHtmlEntity,
+		"findComponentAssets":		findComponentAssets,
 		"findAttributes":			findAttributes,
-
-		"findNumericSlice":			findNumericSlice,
-		"findBooleanSlice":			findBooleanSlice,
-		"findStringSlice":			findStringSlice,
-		"findSliceSlice":			findSliceSlice,
-		"findBoolBoolMap":			findBoolBoolMap,
-		"findBoolNumericMap":		findBoolNumericMap,
-		"findBoolStringMap":		findBoolStringMap,
-		"findNumericBoolMap":		findNumericBoolMap, 
-		"findNumericNumericMap":	findNumericNumericMap,
-		"findNumericStringMap":		findNumericStringMap,
-		"findStringBoolMap":		findStringBoolMap, 
-		"findStringNumericMap":		findStringNumericMap,
-		"findStringStringMap":		findStringStringMap,
-		"findSliceMap":				findSliceMap,
+		"findFrontMatterYaml":		findFrontMatterYaml,
+		"findFrontMatterToml":		findFrontMatterToml,
+		"findPoPluralForms":		findPoPluralForms,
+		"findTranslationPlaceholder":	findTranslationPlaceholder,
+		"findRelativeDate":			findRelativeDate,
 	}
 }
 
@@ -1214,4 +1769,272 @@ func stripExtension(file string, extensions ...string) string {
 
 	index := strings.LastIndex(file, extension)
 	return file[:index] 
-}
\ No newline at end of file
+}
+
+// Lists the bare and the namespaced tag names that a component file may be used with.
+// `directory` is the registered component directory and `file` the component path relative to it.
+func componentNames(directory string, file string, extension string, kebab bool) ([]string, []string) {
+	name		:= stripExtension(path.Base(file), extension)
+	kebabName	:= kebabCase(name)
+	namespace	:= path.Dir(file)
+	bare		:= []string{name}
+	names		:= []string{}
+
+	// Single word names would clash with real HTML elements (e.g. `<button>`)
+	kebab = kebab && strings.Contains(kebabName, "-")
+
+	if kebab {
+		bare = append(bare, kebabName)
+	}
+
+	if namespace == "." {
+		namespace = path.Base(directory)
+	}
+
+	segments	:= strings.Split(namespace, "/")
+	titled		:= make([]string, len(segments))
+	for i, segment := range segments {
+		titled[i] = strings.ToUpper(segment[:1]) + segment[1:]
+	}
+
+	names = append(names, strings.Join(segments, ":") + ":" + name)
+	names = append(names, strings.Join(titled, ".") + "." + name)
+
+	if kebab {
+		names = append(names, strings.Join(segments, ":") + ":" + kebabName)
+	}
+
+	return bare, names
+}
+
+// Converts a PascalCase name into kebab-case (e.g. "VideoEmbed" => "video-embed", "HTMLBlock" => "html-block")
+func kebabCase(name string) string {
+	runes	:= []rune(name)
+	kebab	:= []rune{}
+
+	for i, r := range runes {
+		if unicode.IsUpper(r) && i > 0 {
+			previous	:= runes[i - 1]
+			nextLower	:= i + 1 < len(runes) && unicode.IsLower(runes[i + 1])
+
+			if unicode.IsLower(previous) || unicode.IsDigit(previous) || (unicode.IsUpper(previous) && nextLower) {
+				kebab = append(kebab, '-')
+			}
+		}
+		kebab = append(kebab, unicode.ToLower(r))
+	}
+
+	return string(kebab)
+}
+
+/*
+Finds the start and end of every action (e.g. `{{ .Title }}`) within `content`. Delimiters within quoted strings and
+comments do not end an action, so `{{ printf "}}" }}` is a single action
+*/
+func findTemplateActions(content string, left string, right string) [][2]int {
+	actions := [][2]int{}
+
+	for i := 0; ; {
+		start := strings.Index(content[i:], left)
+		if start < 0 {
+			return actions
+		}
+		start += i
+
+		i = templateActionEnd(content, start + len(left), right)
+		actions = append(actions, [2]int{start, i})
+	}
+}
+
+// Finds the end of the action whose contents begin at `i` (or the end of `content` if it is never closed)
+func templateActionEnd(content string, i int, right string) int {
+	for i < len(content) {
+		switch {
+			case strings.HasPrefix(content[i:], right):
+				return i + len(right)
+			case strings.HasPrefix(content[i:], "/*"):
+				close := strings.Index(content[i + 2:], "*/")
+				if close < 0 {
+					return len(content)
+				}
+				i += close + 4
+			case content[i] == '"' || content[i] == '\'' || content[i] == '`':
+				quote := content[i]
+				for i++; i < len(content) && content[i] != quote; i++ {
+					if content[i] == '\\' && quote != '`' {
+						i++
+					}
+				}
+				i++
+			default:
+				i++
+		}
+	}
+
+	return len(content)
+}
+
+// Finds all uses of a component's tag within `content`: self-closing tags first, then wrapping tags and finally
+// unclosed tags (which are searched for once the others are removed so that opening tags are never matched twice)
+func findComponentTags(component string, content string, collected bool) [][]string {
+	prefix := component + "_findComponents"
+	if collected {
+		prefix += "Collected"
+	}
+
+	matches		:= regexps[prefix + "SelfClosing"].FindAllStringSubmatch(content, -1)
+	matches		= append(matches, regexps[prefix + "Double"].FindAllStringSubmatch(content, -1)...)
+	remaining	:= content
+
+	for _, match := range matches {
+		remaining = strings.Replace(remaining, match[0], "", 1)
+	}
+
+	return append(matches, regexps[prefix + "Single"].FindAllStringSubmatch(remaining, -1)...)
+}
+
+// The name of the variable that collected (`x-`) components are passed to their parent as
+func componentVariableName(componentPath string) string {
+	name := path.Base(componentPath)
+	if index := strings.Index(name, "."); index > 0 {
+		name = name[:index]
+	}
+
+	return name
+}
+
+// Finds the parameters originally passed to the page from within any (possibly nested) component, so that they may
+// be passed on to the next component
+func componentRoot(data any) componentRootCarrier {
+	switch collection := data.(type) {
+		case map[string]any:
+			if root, ok := collection[componentRootKey].(componentRootCarrier); ok {
+				return root
+			}
+		case Params:
+			if root, ok := collection[componentRootKey].(componentRootCarrier); ok {
+				return root
+			}
+	}
+
+	return componentRootCarrier{params: data}
+}
+
+// Unwraps the parameters originally passed to the page (for components that inherit "Root")
+func componentRootParams(root componentRootCarrier) any {
+	return root.params
+}
+
+// Safely reads the value `name` from the data a component was called with (`nil` if it does not exist)
+func componentInherit(data any, name string) any {
+	value, _ := reflectHelperCheckNilPointers(reflect.ValueOf(data))
+	value = reflect.Indirect(value)
+
+	switch value.Kind() {
+		case reflect.Map:
+			if value.Len() > 0 {
+				if found, err := reflectHelperGetMapValue(value, reflect.ValueOf(name)); err == nil && found.IsValid() {
+					return found.Interface()
+				}
+			}
+		case reflect.Struct:
+			if field, ok := value.Type().FieldByName(name); ok && field.IsExported() {
+				if found, err := value.FieldByIndexErr(field.Index); err == nil {
+					return found.Interface()
+				}
+			}
+	}
+
+	return nil
+}
+
+// Describes an invalid variable with the file and line on which it was found.
+// `offset` is the position of the variable's value within `source`.
+func variableError(name string, source string, offset int, varName string, err error) error {
+	line := strings.Count(source[:offset], "\n") + 1
+
+	if syntaxError, ok := err.(*literalSyntaxError); ok {
+		return fmt.Errorf("%s:%d: invalid value for var %q: %s", name, line + syntaxError.Line - 1, varName, syntaxError.Message)
+	}
+
+	return fmt.Errorf("%s:%d: invalid value for var %q: %s", name, line, varName, err.Error())
+}
+
+// Normalises a directory relative to the templates directory ("" is the templates directory itself)
+func cleanDirectory(directory string) string {
+	directory = strings.Trim(path.Clean("/" + filepath.ToSlash(directory)), "/")
+
+	return directory
+}
+
+// Lists the directories that contain the template `name`, from the templates directory itself down to its own
+func templateDirectories(name string) []string {
+	directories	:= []string{""}
+	segments	:= strings.Split(path.Dir(name), "/")
+
+	for i := range segments {
+		if segments[i] != "." {
+			directories = append(directories, strings.Join(segments[:i + 1], "/"))
+		}
+	}
+
+	return directories
+}
+
+// Copies render data into a new `Params` map. Maps must have string keys, and structs (or pointers to them) are
+// flattened into their exported fields (including those promoted from embedded structs)
+func dataToParams(data any) (Params, error) {
+	params := Params{}
+
+	switch typed := data.(type) {
+		case nil:
+			return params, nil
+		case Params:
+			for key, value := range typed {
+				params[key] = value
+			}
+			return params, nil
+		case map[string]any:
+			for key, value := range typed {
+				params[key] = value
+			}
+			return params, nil
+	}
+
+	value := reflect.ValueOf(data)
+	for value.Kind() == reflect.Pointer {
+		if value.IsNil() {
+			return params, nil
+		}
+		value = value.Elem()
+	}
+
+	switch value.Kind() {
+		case reflect.Map:
+			if value.Type().Key().Kind() != reflect.String {
+				return nil, fmt.Errorf("cannot render with data of type %T: map keys must be strings", data)
+			}
+
+			iter := value.MapRange()
+			for iter.Next() {
+				params[iter.Key().String()] = iter.Value().Interface()
+			}
+		case reflect.Struct:
+			for _, field := range reflect.VisibleFields(value.Type()) {
+				if !field.IsExported() {
+					continue
+				}
+
+				fieldValue, err := value.FieldByIndexErr(field.Index)
+				if err != nil {
+					continue
+				}
+
+				params[field.Name] = fieldValue.Interface()
+			}
+		default:
+			return nil, fmt.Errorf("cannot render with data of type %T: must be a map or a struct", data)
+	}
+
+	return params, nil
+}
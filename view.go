@@ -0,0 +1,501 @@
+package templateManager
+
+/*
+Functions dedicated to typed views: entry templates which are always rendered with the same Go type, whose fields are
+checked against the template's schema when the templates are parsed
+*/
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"text/template/parse"
+
+	"golang.org/x/exp/slices"
+)
+
+// Renders a single entry template with data of type `T`, as returned by `RegisterView()`
+type View[T any] struct {
+	tm		*TemplateManager
+	view	*registeredView
+}
+
+// The details of a view which are needed when the templates are parsed
+type registeredView struct {
+	name		string
+	typ			reflect.Type
+	methods		[]string
+	callable	[]string
+	err			error
+}
+
+// A method of a view's data, which is called (at most once per render) when the template first uses its result
+type viewMethodCall struct {
+	view	string
+	name	string
+	method	reflect.Value
+	called	bool
+	result	any
+	err		error
+}
+
+// Calls the method the first time that its result is needed
+func (c *viewMethodCall) value() (any, error) {
+	if !c.called {
+		c.called = true
+
+		results := c.method.Call(nil)
+		if len(results) == 2 && !results[1].IsNil() {
+			c.err = fmt.Errorf("method %s: %s", c.name, results[1].Interface().(error).Error())
+			if len(c.view) > 0 {
+				c.err = fmt.Errorf("view %s: %s", c.view, c.err.Error())
+			}
+		} else {
+			c.result = results[0].Interface()
+		}
+	}
+
+	return c.result, c.err
+}
+
+// Registers the `name` template as a view of `T` (usually a struct). When the templates are parsed, every field that
+// the template's bundle uses is checked against the fields and methods of `T`, and `Parse()` returns an error
+// listing any that do not exist. Fields provided by vars or other params do not need to exist on `T`
+func RegisterView[T any](tm *TemplateManager, name string) *View[T] {
+	view := &registeredView{name: name, typ: reflect.TypeOf((*T)(nil)).Elem()}
+	view.callable = viewCallableMethods(view.typ)
+
+	tm.mutex.Lock()
+	defer tm.mutex.Unlock()
+
+	tm.views = append(tm.views, view)
+
+	if tm.parsed {
+		view.err = tm.checkView(view)
+		if view.err != nil {
+			logError(view.err.Error())
+		}
+	}
+
+	return &View[T]{tm: tm, view: view}
+}
+
+// Returns the name of the template rendered by the view
+func (v *View[T]) Name() string {
+	return v.view.name
+}
+
+// Executes the view's template with `data`
+func (v *View[T]) Render(writer io.Writer, data T) error {
+	return v.render(writer, nil, data)
+}
+
+// Executes the view's template with `data` and the variables created by all context processors from the `request`
+func (v *View[T]) RenderRequest(writer io.Writer, request *http.Request, data T) error {
+	return v.render(writer, request, data)
+}
+
+// Converts `data` to params (with every top level method ready to be called, so that templates which are reloaded may
+// start to use them) and renders the template
+func (v *View[T]) render(writer io.Writer, request *http.Request, data T) error {
+	if ! v.tm.parsed {
+		err := v.tm.Parse()
+		if err != nil {
+			err = logError(err.Error())
+			return err
+		}
+	}
+
+	// Reloaded templates are checked again when they are rendered
+	if v.view.err != nil && !v.tm.reload {
+		return v.view.err
+	}
+
+	params, err := dataToParams(data)
+	if err != nil {
+		err = logError(err.Error())
+		return err
+	}
+
+	value := reflect.ValueOf(data)
+	if len(v.view.callable) > 0 && value.Kind() != reflect.Pointer {
+		pointer := reflect.New(value.Type())
+		pointer.Elem().Set(value)
+		value = pointer
+	}
+
+	for _, name := range v.view.callable {
+		if value.IsNil() {
+			break
+		}
+
+		params[name] = &viewMethodCall{view: v.view.name, name: name, method: value.MethodByName(name)}
+	}
+
+	if request != nil {
+		return v.tm.RenderRequest(writer, request, v.view.name, params)
+	}
+
+	return v.tm.Render(v.view.name, params, writer)
+}
+
+// Checks every registered view (called once all templates have been parsed)
+func (tm *TemplateManager) checkViews() error {
+	problems := []string{}
+	for _, view := range tm.views {
+		view.err = tm.checkView(view)
+		if view.err != nil {
+			problems = append(problems, view.err.Error())
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("%s", strings.Join(problems, "\n"))
+	}
+
+	return nil
+}
+
+// Checks the views of a single template again once it has been re-parsed (so that uses of their methods are rewritten)
+func (tm *TemplateManager) checkTemplateViews(name string) error {
+	problems := []string{}
+	for _, view := range tm.views {
+		if view.name != name {
+			continue
+		}
+
+		view.err = tm.checkView(view)
+		if view.err != nil {
+			problems = append(problems, view.err.Error())
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("%s", strings.Join(problems, "\n"))
+	}
+
+	return nil
+}
+
+// Checks the schema of a single view's template against its type, finding the top level methods that it uses
+func (tm *TemplateManager) checkView(view *registeredView) error {
+	schema, err := tm.buildSchema(view.name)
+	if err != nil {
+		return fmt.Errorf("view %s: %s", view.name, err.Error())
+	}
+
+	view.methods = []string{}
+	problems := []string{}
+
+	for _, field := range schema.Fields {
+		typ, method, known := viewFieldType(view.typ, field.Name, true)
+		if !known {
+			if !field.Provided {
+				problems = append(problems, field.paths("")[0])
+			}
+			continue
+		}
+
+		if method != nil {
+			if !viewMethodCallable(*method) {
+				problems = append(problems, field.Name + " (top level methods must take no arguments and return a value and an optional error)")
+				continue
+			}
+			view.methods = append(view.methods, field.Name)
+		}
+
+		problems = append(problems, checkViewField(field, typ, "")...)
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("view %s: %s does not have the fields used by the template: %s", view.name, view.typ, strings.Join(problems, ", "))
+	}
+
+	if len(view.methods) > 0 {
+		rewriteViewMethods(tm.templates[view.name].Trees(), view.methods)
+
+		tm.localeMutex.Lock()
+		if source, ok := tm.localeSources[view.name]; ok {
+			rewriteViewMethods(source.Trees(), view.methods)
+		}
+		for _, localised := range tm.localeTemplates[view.name] {
+			rewriteViewMethods(localised.Trees(), view.methods)
+		}
+		tm.localeMutex.Unlock()
+	}
+
+	return nil
+}
+
+// Reads the `name` field of `data` in the same way as `text/template` does, calling a view's method the first time
+// that the template uses its result. Every use of a view method is rewritten to call this function
+func viewMethod(data any, name string) (any, error) {
+	value, nilPointer := reflectHelperCheckNilPointers(reflect.ValueOf(data))
+	if nilPointer || !value.IsValid() {
+		return nil, nil
+	}
+
+	if method := value.MethodByName(name); method.IsValid() {
+		if method.Type().NumIn() != 0 || !viewMethodResults(method.Type()) {
+			return nil, fmt.Errorf("method %s must take no arguments and return a value and an optional error", name)
+		}
+
+		return (&viewMethodCall{name: name, method: method}).value()
+	}
+
+	for value.Kind() == reflect.Pointer {
+		value = value.Elem()
+	}
+
+	var result reflect.Value
+	switch value.Kind() {
+		case reflect.Map:
+			if value.Type().Key().Kind() == reflect.String {
+				result = value.MapIndex(reflect.ValueOf(name).Convert(value.Type().Key()))
+				if !result.IsValid() {
+					return nil, nil
+				}
+			}
+		case reflect.Struct:
+			if field, ok := value.Type().FieldByName(name); ok && field.IsExported() {
+				result = value.FieldByIndex(field.Index)
+			}
+	}
+
+	if !result.IsValid() {
+		return nil, fmt.Errorf("can't evaluate field %s in type %s", name, value.Type())
+	}
+
+	if call, ok := result.Interface().(*viewMethodCall); ok {
+		return call.value()
+	}
+
+	return result.Interface(), nil
+}
+
+// Rewrites every use of a view method in the trees as a call to `viewMethod`, so that the method is only called if
+// the template reaches it (e.g. `.Total.Amount` becomes `(viewMethod . "Total").Amount`)
+func rewriteViewMethods(trees map[string]*parse.Tree, methods []string) {
+	for _, tree := range trees {
+		rewriteViewNode(tree.Root, methods)
+	}
+}
+
+// Rewrites the uses of view methods within a single node
+func rewriteViewNode(node parse.Node, methods []string) {
+	switch typed := node.(type) {
+		case *parse.ListNode:
+			if typed == nil {
+				return
+			}
+			for _, child := range typed.Nodes {
+				rewriteViewNode(child, methods)
+			}
+		case *parse.ActionNode:
+			rewriteViewPipe(typed.Pipe, methods)
+		case *parse.IfNode:
+			rewriteViewBranch(&typed.BranchNode, methods)
+		case *parse.WithNode:
+			rewriteViewBranch(&typed.BranchNode, methods)
+		case *parse.RangeNode:
+			rewriteViewBranch(&typed.BranchNode, methods)
+		case *parse.TemplateNode:
+			rewriteViewPipe(typed.Pipe, methods)
+	}
+}
+
+// Rewrites the uses of view methods within `if`, `with` and `range`
+func rewriteViewBranch(branch *parse.BranchNode, methods []string) {
+	rewriteViewPipe(branch.Pipe, methods)
+	rewriteViewNode(branch.List, methods)
+	rewriteViewNode(branch.ElseList, methods)
+}
+
+// Rewrites the uses of view methods within the arguments of a pipeline
+func rewriteViewPipe(pipe *parse.PipeNode, methods []string) {
+	if pipe == nil {
+		return
+	}
+
+	for _, command := range pipe.Cmds {
+		for i, argument := range command.Args {
+			command.Args[i] = rewriteViewArgument(argument, methods)
+		}
+	}
+}
+
+// Rewrites a single argument if it uses a view method (`.Name...` or `$.Name...`)
+func rewriteViewArgument(node parse.Node, methods []string) parse.Node {
+	switch typed := node.(type) {
+		case *parse.FieldNode:
+			if slices.Contains(methods, typed.Ident[0]) {
+				return viewMethodNode(&parse.DotNode{NodeType: parse.NodeDot, Pos: typed.Pos}, typed.Ident, typed.Pos)
+			}
+		case *parse.VariableNode:
+			if typed.Ident[0] == "$" && len(typed.Ident) > 1 && slices.Contains(methods, typed.Ident[1]) {
+				return viewMethodNode(&parse.VariableNode{NodeType: parse.NodeVariable, Pos: typed.Pos, Ident: []string{"$"}}, typed.Ident[1:], typed.Pos)
+			}
+		case *parse.ChainNode:
+			typed.Node = rewriteViewArgument(typed.Node, methods)
+		case *parse.PipeNode:
+			rewriteViewPipe(typed, methods)
+	}
+
+	return node
+}
+
+// Creates the node which reads `fields[0]` of `receiver` with `viewMethod`, followed by any remaining fields
+func viewMethodNode(receiver parse.Node, fields []string, pos parse.Pos) parse.Node {
+	call := &parse.PipeNode{NodeType: parse.NodePipe, Pos: pos, Cmds: []*parse.CommandNode{{
+		NodeType:	parse.NodeCommand,
+		Pos:		pos,
+		Args:		[]parse.Node{
+			parse.NewIdentifier("viewMethod").SetPos(pos),
+			receiver,
+			&parse.StringNode{NodeType: parse.NodeString, Pos: pos, Quoted: strconv.Quote(fields[0]), Text: fields[0]},
+		},
+	}}}
+
+	if len(fields) == 1 {
+		return call
+	}
+
+	return &parse.ChainNode{NodeType: parse.NodeChain, Pos: pos, Node: call, Field: fields[1:]}
+}
+
+// Finds the field read by a command which was rewritten to call `viewMethod` (see `viewMethodNode()`)
+func viewMethodCommand(command *parse.CommandNode) (parse.Node, string, bool) {
+	if len(command.Args) != 3 {
+		return nil, "", false
+	}
+
+	identifier, ok := command.Args[0].(*parse.IdentifierNode)
+	if !ok || identifier.Ident != "viewMethod" {
+		return nil, "", false
+	}
+
+	name, ok := command.Args[2].(*parse.StringNode)
+	if !ok {
+		return nil, "", false
+	}
+
+	return command.Args[1], name.Text, true
+}
+
+// Checks the nested fields of a schema field against its type (nil when the type cannot be known), returning the
+// paths of any that do not exist
+func checkViewField(field *SchemaField, typ reflect.Type, prefix string) []string {
+	if typ == nil {
+		return []string{}
+	}
+
+	path := prefix + field.Name
+	if field.Collection {
+		path += "[]"
+
+		typ = viewElementType(typ)
+		if typ == nil {
+			return []string{path + " (not a collection)"}
+		}
+	}
+
+	problems := []string{}
+	for _, child := range field.Fields {
+		childType, _, known := viewFieldType(typ, child.Name, false)
+		if !known {
+			problems = append(problems, child.paths(path + ".")[0])
+			continue
+		}
+
+		problems = append(problems, checkViewField(child, childType, path + ".")...)
+	}
+
+	return problems
+}
+
+// Finds the type of the `name` field or method of `typ` in the same way as `text/template` does. The type is nil when
+// it cannot be known (e.g. an interface), and the method is returned if `name` is a method. Pointer methods are only
+// found for `addressable` values
+func viewFieldType(typ reflect.Type, name string, addressable bool) (reflect.Type, *reflect.Method, bool) {
+	if typ.Kind() == reflect.Interface {
+		return nil, nil, true
+	}
+
+	methods := typ
+	if addressable && typ.Kind() != reflect.Pointer {
+		methods = reflect.PointerTo(typ)
+	}
+	if method, ok := methods.MethodByName(name); ok {
+		if method.Type.NumOut() == 0 {
+			return nil, &method, false
+		}
+		return method.Type.Out(0), &method, true
+	}
+
+	for typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+
+	switch typ.Kind() {
+		case reflect.Struct:
+			field, ok := typ.FieldByName(name)
+			if ok && field.IsExported() {
+				return field.Type, nil, true
+			}
+		case reflect.Map:
+			if typ.Key().Kind() == reflect.String {
+				return typ.Elem(), nil, true
+			}
+		case reflect.Interface:
+			return nil, nil, true
+	}
+
+	return nil, nil, false
+}
+
+// Finds the type of each item when ranging over `typ` (nil if it cannot be ranged over)
+func viewElementType(typ reflect.Type) reflect.Type {
+	for typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+
+	switch typ.Kind() {
+		case reflect.Slice, reflect.Array, reflect.Map, reflect.Chan:
+			return typ.Elem()
+		case reflect.Interface:
+			return reflect.TypeOf((*any)(nil)).Elem()
+	}
+
+	return nil
+}
+
+// Lists the methods of `typ` (including those of a pointer to it) which can be called to create top level params
+func viewCallableMethods(typ reflect.Type) []string {
+	if typ.Kind() != reflect.Pointer && typ.Kind() != reflect.Interface {
+		typ = reflect.PointerTo(typ)
+	}
+
+	methods := []string{}
+	for i := 0; i < typ.NumMethod(); i++ {
+		if method := typ.Method(i); viewMethodCallable(method) {
+			methods = append(methods, method.Name)
+		}
+	}
+
+	return methods
+}
+
+// Checks that a method can be called to create a top level param (no arguments, returning a value and optional error)
+func viewMethodCallable(method reflect.Method) bool {
+	return method.Type.NumIn() == 1 && viewMethodResults(method.Type)
+}
+
+// Checks that a method returns a value and an optional error
+func viewMethodResults(methodType reflect.Type) bool {
+	errorType := reflect.TypeOf((*error)(nil)).Elem()
+
+	return methodType.NumOut() == 1 || (methodType.NumOut() == 2 && methodType.Out(1) == errorType)
+}